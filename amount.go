@@ -0,0 +1,180 @@
+package bca
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// Amount represents money amount in hundredths (e.g. 100000.00 is Amount(10000000)).
+// It is (un)marshalled to JSON as string with 2 decimals, e.g. "100000.00".
+type Amount int64
+
+// currencyMinorUnits is number of decimals of currencies supported by BCA
+var currencyMinorUnits = map[string]int{
+	"IDR": 2,
+	"USD": 2,
+	"SGD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"AUD": 2,
+	"NZD": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"HKD": 2,
+	"CNY": 2,
+	"MYR": 2,
+	"THB": 2,
+	"SAR": 2,
+	"JPY": 0,
+	"KRW": 0,
+}
+
+// ErrAmountOverflow is panic value of Amount arithmetic whose result does not fit in Amount
+var ErrAmountOverflow = errors.New("amount overflow")
+
+// NewAmount return amount of units and hundredths, e.g. NewAmount(100000, 50) is 100000.50.
+// hundredths takes the sign of non-zero units, e.g. NewAmount(-1, 50) is -1.50.
+// It panics with ErrAmountOverflow if the amount does not fit in Amount.
+func NewAmount(units, hundredths int64) Amount {
+	if (units < 0 && hundredths > 0) || (units > 0 && hundredths < 0) {
+		hundredths = -hundredths
+	}
+	return Amount(units).Mul(100).Add(Amount(hundredths))
+}
+
+// ParseAmount parse decimal amount having up to 2 decimals, e.g. "100000", "100000.5" or "-100000.00"
+func ParseAmount(s string) (Amount, error) {
+	digits := strings.TrimPrefix(s, "-")
+	units, hundredths := digits, "00"
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		units, hundredths = digits[:i], digits[i+1:]
+		if len(hundredths) == 0 || len(hundredths) > 2 {
+			return 0, errors.NotValidf("amount %q", s)
+		}
+		hundredths += strings.Repeat("0", 2-len(hundredths))
+	}
+	if units == "" || strings.Trim(units+hundredths, "0123456789") != "" {
+		return 0, errors.NotValidf("amount %q", s)
+	}
+
+	amount, err := strconv.ParseInt(units+hundredths, 10, 64)
+	if err != nil {
+		return 0, errors.NotValidf("amount %q", s)
+	}
+	if len(digits) != len(s) {
+		amount = -amount
+	}
+	return Amount(amount), nil
+}
+
+// MustParseAmount is like ParseAmount but panics if s can not be parsed
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func (a Amount) String() string {
+	sign, abs := "", uint64(a)
+	if a < 0 {
+		sign, abs = "-", uint64(-(a+1))+1
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+// Float64 return a as float64, it may be rounded
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// Add return a + b, it panics with ErrAmountOverflow on overflow
+func (a Amount) Add(b Amount) Amount {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		panic(errors.Annotatef(ErrAmountOverflow, "%s + %s", a, b))
+	}
+	return sum
+}
+
+// Sub return a - b, it panics with ErrAmountOverflow on overflow
+func (a Amount) Sub(b Amount) Amount {
+	diff := a - b
+	if (b > 0 && diff > a) || (b < 0 && diff < a) {
+		panic(errors.Annotatef(ErrAmountOverflow, "%s - %s", a, b))
+	}
+	return diff
+}
+
+// Mul return a * n, it panics with ErrAmountOverflow on overflow
+func (a Amount) Mul(n int64) Amount {
+	if a == 0 || n == 0 {
+		return 0
+	}
+	product := a * Amount(n)
+	if product/Amount(n) != a || (a == -1 && n == math.MinInt64) || (n == -1 && a == math.MinInt64) {
+		panic(errors.Annotatef(ErrAmountOverflow, "%s * %d", a, n))
+	}
+	return product
+}
+
+// Cmp return -1 if a < b, 0 if a == b, +1 if a > b
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Validate check a is positive amount of currencyCode (e.g. IDR, USD), which must be supported by BCA
+func (a Amount) Validate(currencyCode string) error {
+	minorUnits, ok := currencyMinorUnits[currencyCode]
+	if !ok {
+		return errors.NotValidf("currency %q", currencyCode)
+	}
+	if a <= 0 {
+		return errors.NotValidf("amount %s", a)
+	}
+	if minorUnits == 0 && a%100 != 0 {
+		return errors.NotValidf("amount %s of %s having no decimals", a, currencyCode)
+	}
+	return nil
+}
+
+// MarshalJSON marshal a as string with 2 decimals
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + a.String() + `"`), nil
+}
+
+// UnmarshalJSON unmarshal either string (empty string is zero) or number
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return errors.Trace(err)
+		}
+		if s == "" {
+			*a = 0
+			return nil
+		}
+	}
+
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*a = amount
+	return nil
+}
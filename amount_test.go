@@ -0,0 +1,108 @@
+package bca_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/purwaren/bca-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAmount_JSON(t *testing.T) {
+	data, err := json.Marshal(bca.FundTransferRequest{Amount: bca.NewAmount(100000, 0)})
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"Amount":"100000.00"`)
+
+	data, err = json.Marshal([]bca.Amount{bca.NewAmount(0, 5), bca.MustParseAmount("-1.5"), bca.NewAmount(922337203685477, 80)})
+	require.NoError(t, err)
+	require.Equal(t, `["0.05","-1.50","922337203685477.80"]`, string(data))
+
+	tests := []struct {
+		json     string
+		expected bca.Amount
+		wantErr  bool
+	}{
+		{`"100000.00"`, bca.NewAmount(100000, 0), false},
+		{`"100000"`, bca.NewAmount(100000, 0), false},
+		{`"0.5"`, bca.NewAmount(0, 50), false},
+		{`"-12.34"`, bca.MustParseAmount("-12.34"), false},
+		{`""`, 0, false},
+		{`100000.01`, bca.NewAmount(100000, 1), false},
+		{`100000`, bca.NewAmount(100000, 0), false},
+		{`"1.234"`, 0, true},
+		{`"1,000.00"`, 0, true},
+		{`"1."`, 0, true},
+		{`"abc"`, 0, true},
+		{`1e5`, 0, true},
+		{`"99999999999999999999"`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var dtoResp struct{ Amount bca.Amount }
+			err := json.Unmarshal([]byte(`{"Amount":`+tt.json+`}`), &dtoResp)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, dtoResp.Amount)
+		})
+	}
+}
+
+func TestAmount_arithmetic(t *testing.T) {
+	a, b := bca.MustParseAmount("0.10"), bca.MustParseAmount("0.20")
+	require.Equal(t, "0.30", a.Add(b).String())
+	require.Equal(t, "-0.10", a.Sub(b).String())
+	require.Equal(t, "0.30", a.Mul(3).String())
+	require.Equal(t, -1, a.Cmp(b))
+	require.Equal(t, 0, a.Cmp(bca.NewAmount(0, 10)))
+	require.Equal(t, 1, b.Cmp(a))
+	require.Equal(t, 0.1, a.Float64())
+
+	t.Run("sign of units", func(t *testing.T) {
+		require.Equal(t, "-1.50", bca.NewAmount(-1, 50).String())
+		require.Equal(t, "-1.50", bca.NewAmount(-1, -50).String())
+		require.Equal(t, "1.50", bca.NewAmount(1, -50).String())
+		require.Equal(t, "-0.50", bca.NewAmount(0, -50).String())
+		require.Equal(t, bca.MustParseAmount("-1.50"), bca.NewAmount(-1, 50))
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		max, min := bca.Amount(math.MaxInt64), bca.Amount(math.MinInt64)
+		require.Equal(t, "-92233720368547758.08", min.String())
+
+		overflows := map[string]func(){
+			"Add":       func() { max.Add(bca.NewAmount(0, 1)) },
+			"Add min":   func() { min.Add(bca.NewAmount(0, -1)) },
+			"Sub":       func() { min.Sub(bca.NewAmount(0, 1)) },
+			"Sub max":   func() { max.Sub(bca.NewAmount(0, -1)) },
+			"Mul":       func() { max.Mul(2) },
+			"Mul -1":    func() { min.Mul(-1) },
+			"NewAmount": func() { bca.NewAmount(math.MaxInt64/100, 100) },
+		}
+		for name, overflow := range overflows {
+			func() {
+				defer func() {
+					err, _ := recover().(error)
+					require.Equal(t, bca.ErrAmountOverflow, errors.Cause(err), name)
+				}()
+				overflow()
+			}()
+		}
+		require.Equal(t, max, max.Sub(bca.NewAmount(0, 1)).Add(bca.NewAmount(0, 1)))
+		require.Equal(t, min.Add(bca.NewAmount(0, 1)), max.Mul(-1))
+	})
+}
+
+func TestAmount_Validate(t *testing.T) {
+	require.NoError(t, bca.NewAmount(100000, 0).Validate("IDR"))
+	require.NoError(t, bca.NewAmount(10, 25).Validate("USD"))
+	require.NoError(t, bca.NewAmount(1000, 0).Validate("JPY"))
+	require.True(t, errors.IsNotValid(bca.NewAmount(1000, 50).Validate("JPY")))
+	require.True(t, errors.IsNotValid(bca.NewAmount(100000, 0).Validate("XYZ")))
+	require.True(t, errors.IsNotValid(bca.NewAmount(0, 0).Validate("IDR")))
+	require.True(t, errors.IsNotValid(bca.MustParseAmount("-1").Validate("IDR")))
+}
@@ -10,12 +10,10 @@ import (
 	"net/url"
 	"path"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/juju/errors"
-	"github.com/lithammer/shortuuid"
 	"github.com/purwaren/bca-api/logger"
 	"go.uber.org/zap"
 
@@ -29,32 +27,28 @@ var (
 
 type api struct {
 	config     Config
-	httpClient *http.Client // for postGetToken only
+	httpClient *http.Client
 
-	mutex       sync.Mutex
-	accessToken string
-	bcaSessID   string
+	tokens *tokenManager
 }
 
 func newAPI(config Config) *api {
 
-	httpClient := cleanhttp.DefaultPooledClient()
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = cleanhttp.DefaultPooledClient()
+	}
 
 	api := api{config: config,
 		httpClient: httpClient,
 	}
+	api.tokens = newTokenManager(api.postGetToken, config.TokenStore, config.TokenRefreshBefore)
 
 	return &api
 }
 
-func (api *api) setAccessToken(accessToken string) {
-	api.mutex.Lock()
-	defer api.mutex.Unlock()
-
-	newSessID := shortuuid.New()
-
-	api.accessToken = accessToken
-	api.bcaSessID = newSessID
+func (api *api) sessID() string {
+	return api.tokens.sessID()
 }
 
 // === AUTH ===
@@ -77,26 +71,8 @@ func (api *api) postGetToken(ctx context.Context) (*AuthToken, error) {
 	req.Header.Set("content-type", "application/x-www-form-urlencoded")
 	req.SetBasicAuth(api.config.ClientID, api.config.ClientSecret)
 
-	resp, err := api.httpClient.Do(req)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	defer resp.Body.Close()
-
-	bodyRespBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	api.log(ctx).Info(resp.StatusCode)
-	api.log(ctx).Info(string(bodyRespBytes))
-
-	resp.Body = ioutil.NopCloser(bytes.NewBuffer(bodyRespBytes))
-
 	var dtoResp AuthToken
-	err = json.NewDecoder(resp.Body).Decode(&dtoResp)
-
-	if err != nil {
+	if err := api.do(ctx, req, http.MethodPost+" /api/oauth/token", &dtoResp); err != nil {
 		return nil, errors.Trace(err)
 	}
 
@@ -105,15 +81,30 @@ func (api *api) postGetToken(ctx context.Context) (*AuthToken, error) {
 
 // === BANKING ===
 func (api *api) bankingGetBalance(ctx context.Context, dtoReq BalanceInfoRequest) (*BalanceInfoResponse, error) {
-	path := fmt.Sprintf("/banking/v3/corporates/%s/accounts/%s", api.config.CorporateID, dtoReq.AccountNumber)
+	path := fmt.Sprintf("/banking/v3/corporates/%s/accounts/%s", api.config.CorporateID, strings.Join(dtoReq.accountNumbers(), ","))
 
 	var balanceInfoResp BalanceInfoResponse
-	if err := api.call(ctx, http.MethodGet, path, nil, []byte(""), &balanceInfoResp); err != nil {
+	if err := api.call(ctx, http.MethodGet, path, nil, nil, []byte(""), &balanceInfoResp); err != nil {
 		return nil, errors.Trace(err)
 	}
 	return &balanceInfoResp, nil
 }
 
+func (api *api) bankingGetStatement(ctx context.Context, dtoReq AccountStatementRequest) (*AccountStatementResponse, error) {
+	path := fmt.Sprintf("/banking/v3/corporates/%s/accounts/%s/statements", api.config.CorporateID, dtoReq.AccountNumber)
+
+	urlQuery := url.Values{
+		"StartDate": []string{dtoReq.StartDate},
+		"EndDate":   []string{dtoReq.EndDate},
+	}
+
+	var accountStatementResp AccountStatementResponse
+	if err := api.call(ctx, http.MethodGet, path, urlQuery, nil, []byte(""), &accountStatementResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &accountStatementResp, nil
+}
+
 func (api *api) bankingPostFundTransfer(ctx context.Context, dtoReq FundTransferRequest) (*FundTransferResponse, error) {
 	path := fmt.Sprintf("/banking/corporates/transfers")
 
@@ -123,7 +114,7 @@ func (api *api) bankingPostFundTransfer(ctx context.Context, dtoReq FundTransfer
 	}
 
 	var fundTransferResp FundTransferResponse
-	if err := api.call(ctx, http.MethodPost, path, nil, jsonReq, &fundTransferResp); err != nil {
+	if err := api.call(ctx, http.MethodPost, path, nil, nil, jsonReq, &fundTransferResp); err != nil {
 		return nil, errors.Trace(err)
 	}
 	return &fundTransferResp, nil
@@ -143,12 +134,47 @@ func (api *api) bankingPostFundTransferDomestic(ctx context.Context, dtoReq Fund
 	}
 
 	var fundTransferDomesticResp FundTransferDomesticResponse
-	if err := api.call(ctx, http.MethodPost, path, headers, jsonReq, &fundTransferDomesticResp); err != nil {
+	if err := api.call(ctx, http.MethodPost, path, nil, headers, jsonReq, &fundTransferDomesticResp); err != nil {
 		return nil, errors.Trace(err)
 	}
 	return &fundTransferDomesticResp, nil
 }
 
+func (api *api) bankingGetTransferStatus(ctx context.Context, dtoReq TransferStatusRequest) (*TransferStatusResponse, error) {
+	path := fmt.Sprintf("/banking/corporates/transfers/v2/status/%s", dtoReq.TransactionID)
+
+	urlQuery := url.Values{
+		"TransactionDate": []string{dtoReq.TransactionDate},
+		"TransferType":    []string{dtoReq.TransferType},
+	}
+
+	headers := map[string]string{
+		httpHeaderChannelID:    api.config.ChannelID,
+		httpHeaderCredentialID: api.config.CredentialID,
+	}
+
+	var transferStatusResp TransferStatusResponse
+	if err := api.call(ctx, http.MethodGet, path, urlQuery, headers, []byte(""), &transferStatusResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &transferStatusResp, nil
+}
+
+func (api *api) bankingGetDomesticAccount(ctx context.Context, dtoReq DomesticAccountRequest) (*DomesticAccountResponse, error) {
+	path := fmt.Sprintf("/banking/corporates/transfers/v2/domestic/beneficiary-banks/%s/accounts/%s", dtoReq.BeneficiaryBankCode, dtoReq.BeneficiaryAccountNumber)
+
+	headers := map[string]string{
+		httpHeaderChannelID:    api.config.ChannelID,
+		httpHeaderCredentialID: api.config.CredentialID,
+	}
+
+	var domesticAccountResp DomesticAccountResponse
+	if err := api.call(ctx, http.MethodGet, path, nil, headers, []byte(""), &domesticAccountResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &domesticAccountResp, nil
+}
+
 func (api *api) firePostInquiryAccount(ctx context.Context, dtoReq InquiryAccountRequest) (*InquiryAccountResponse, error) {
 	path := fmt.Sprintf("/fire/accounts")
 
@@ -158,16 +184,104 @@ func (api *api) firePostInquiryAccount(ctx context.Context, dtoReq InquiryAccoun
 	}
 
 	var inquiryAccountResp InquiryAccountResponse
-	if err := api.call(ctx, http.MethodPost, path, nil, jsonReq, &inquiryAccountResp); err != nil {
+	if err := api.call(ctx, http.MethodPost, path, nil, nil, jsonReq, &inquiryAccountResp); err != nil {
 		return nil, errors.Trace(err)
 	}
 	return &inquiryAccountResp, nil
 }
 
+func (api *api) firePostTransferToAccount(ctx context.Context, dtoReq TransferToAccountRequest) (*TransferToAccountResponse, error) {
+	path := fmt.Sprintf("/fire/transactions/to-account")
+
+	jsonReq, err := json.Marshal(dtoReq)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var transferToAccountResp TransferToAccountResponse
+	if err := api.call(ctx, http.MethodPost, path, nil, nil, jsonReq, &transferToAccountResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &transferToAccountResp, nil
+}
+
+func (api *api) firePostCashTransfer(ctx context.Context, dtoReq CashTransferRequest) (*CashTransferResponse, error) {
+	path := fmt.Sprintf("/fire/transactions/cash-transfer")
+
+	jsonReq, err := json.Marshal(dtoReq)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var cashTransferResp CashTransferResponse
+	if err := api.call(ctx, http.MethodPost, path, nil, nil, jsonReq, &cashTransferResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &cashTransferResp, nil
+}
+
+func (api *api) firePutAmendCashTransfer(ctx context.Context, dtoReq AmendCashTransferRequest) (*AmendCashTransferResponse, error) {
+	path := fmt.Sprintf("/fire/transactions/cash-transfer/amend")
+
+	jsonReq, err := json.Marshal(dtoReq)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var amendCashTransferResp AmendCashTransferResponse
+	if err := api.call(ctx, http.MethodPut, path, nil, nil, jsonReq, &amendCashTransferResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &amendCashTransferResp, nil
+}
+
+func (api *api) firePutCancelCashTransfer(ctx context.Context, dtoReq CancelCashTransferRequest) (*CancelCashTransferResponse, error) {
+	path := fmt.Sprintf("/fire/transactions/cash-transfer/cancel")
+
+	jsonReq, err := json.Marshal(dtoReq)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var cancelCashTransferResp CancelCashTransferResponse
+	if err := api.call(ctx, http.MethodPut, path, nil, nil, jsonReq, &cancelCashTransferResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &cancelCashTransferResp, nil
+}
+
+func (api *api) firePostInquiryTransaction(ctx context.Context, dtoReq InquiryTransactionRequest) (*InquiryTransactionResponse, error) {
+	path := fmt.Sprintf("/fire/transactions")
+
+	jsonReq, err := json.Marshal(dtoReq)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var inquiryTransactionResp InquiryTransactionResponse
+	if err := api.call(ctx, http.MethodPost, path, nil, nil, jsonReq, &inquiryTransactionResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &inquiryTransactionResp, nil
+}
+
+func (api *api) firePostInquiryBalance(ctx context.Context, dtoReq InquiryBalanceRequest) (*InquiryBalanceResponse, error) {
+	path := fmt.Sprintf("/fire/accounts/balance")
+
+	jsonReq, err := json.Marshal(dtoReq)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var inquiryBalanceResp InquiryBalanceResponse
+	if err := api.call(ctx, http.MethodPost, path, nil, nil, jsonReq, &inquiryBalanceResp); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &inquiryBalanceResp, nil
+}
+
 // Generic HTTP request to API
-func (api *api) call(ctx context.Context, httpMethod string, path string, additionalHeader map[string]string, bodyReqPayload []byte, dtoResp interface{}) (err error) {
-	// urlQuery := url.Values{"access_token": []string{api.accessToken}}
-	urlQuery := url.Values{}
+func (api *api) call(ctx context.Context, httpMethod string, path string, urlQuery url.Values, additionalHeader map[string]string, bodyReqPayload []byte, dtoResp interface{}) (err error) {
 	urlTarget, err := buildURL(api.config.URL, path, urlQuery)
 	if err != nil {
 		return errors.Trace(err)
@@ -181,14 +295,25 @@ func (api *api) call(ctx context.Context, httpMethod string, path string, additi
 
 	req.Header.Set("content-type", "application/json")
 
-	req.Header.Set("Authorization", "Bearer "+api.accessToken)
+	accessToken, err := api.tokens.token(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Origin", api.config.OriginHost)
 	req.Header.Set("X-BCA-Key", api.config.APIKey)
 
 	timestamp := time.Now().Format("2006-01-02T15:04:05.999Z07:00")
 	req.Header.Set("X-BCA-Timestamp", timestamp)
 
-	signature, _, err := GenerateSignature(api.config.APISecret, httpMethod, path, api.accessToken, string(bodyReqPayload), timestamp)
+	// query string is part of the signed path, GenerateSignature sorts it
+	signPath := path
+	if len(urlQuery) > 0 {
+		signPath = path + "?" + urlQuery.Encode()
+	}
+
+	signature, _, err := GenerateSignature(api.config.APISecret, httpMethod, signPath, accessToken, string(bodyReqPayload), timestamp)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -202,26 +327,45 @@ func (api *api) call(ctx context.Context, httpMethod string, path string, additi
 		req.Header.Set(key, val)
 	}
 
+	err = api.do(ctx, req, httpMethod+" "+path, dtoResp)
+
+	// token is refused, let the next call fetch a new one
+	if apiErr, ok := err.(*APIError); ok && apiErr.ErrorCode == ErrCodeUnauthorized {
+		api.tokens.invalidate(accessToken)
+	}
+
+	return err
+}
+
+// do send request and decode its response into dtoResp.
+// Error is classified into *TransportError, *APIError, *GatewayError or *DecodeError.
+func (api *api) do(ctx context.Context, req *http.Request, endpoint string, dtoResp interface{}) error {
 	resp, err := api.httpClient.Do(req)
 	if err != nil {
-		return errors.Trace(err)
+		return &TransportError{Endpoint: endpoint, Err: err}
 	}
 	defer resp.Body.Close()
 
 	bodyRespBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return errors.Trace(err)
+		return &TransportError{Endpoint: endpoint, Err: err}
 	}
 
 	api.log(ctx).Info(resp.StatusCode)
 	api.log(ctx).Info(string(bodyRespBytes))
 
-	resp.Body = ioutil.NopCloser(bytes.NewBuffer(bodyRespBytes))
+	// BCA error response may come with any status code
+	var dtoErr Error
+	if err := json.Unmarshal(bodyRespBytes, &dtoErr); err == nil && dtoErr.ErrorCode != "" {
+		return newAPIError(ctx, resp, bodyRespBytes, endpoint, dtoErr)
+	}
 
-	err = json.NewDecoder(resp.Body).Decode(&dtoResp)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &GatewayError{HTTPStatus: resp.StatusCode, Header: resp.Header, Body: bodyRespBytes, Endpoint: endpoint}
+	}
 
-	if err != nil {
-		return errors.Trace(err)
+	if err := json.Unmarshal(bodyRespBytes, dtoResp); err != nil {
+		return &DecodeError{HTTPStatus: resp.StatusCode, Header: resp.Header, Body: bodyRespBytes, Endpoint: endpoint, Err: err}
 	}
 
 	return nil
@@ -229,7 +373,7 @@ func (api *api) call(ctx context.Context, httpMethod string, path string, additi
 
 // === misc func ===
 func (api *api) log(ctx context.Context) *zap.SugaredLogger {
-	return logger.Logger(bcaCtx.With(ctx, bcaCtx.BCASessID(api.bcaSessID)))
+	return logger.Logger(bcaCtx.With(ctx, bcaCtx.BCASessID(api.sessID())))
 }
 
 func buildURL(baseURL, paths string, query url.Values) (string, error) {
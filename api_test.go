@@ -0,0 +1,105 @@
+package bca
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_api_call_signQueryParam(t *testing.T) {
+	givenConfig := Config{
+		APIKey:      "dcc99ba6-3b2f-479b-9f85-86a09ccaaacf",
+		APISecret:   "5e636b16-df7f-4a53-afbe-497e6fe07edc",
+		CorporateID: "BCAAPI2016",
+	}
+
+	var gotPath, gotSign, gotTimestamp string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth/token" {
+			_, _ = w.Write([]byte(`{"access_token":"lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS","token_type":"Bearer","expires_in":3600,"scope":"resource.WRITE resource.READ"}`))
+			return
+		}
+		gotPath = r.URL.RequestURI()
+		gotSign = r.Header.Get("X-BCA-Signature")
+		gotTimestamp = r.Header.Get("X-BCA-Timestamp")
+		_, _ = w.Write([]byte(`{"StartDate":"2016-09-01","EndDate":"2016-09-01","Data":[]}`))
+	}))
+	defer srv.Close()
+
+	givenConfig.URL = srv.URL
+	api := newAPI(givenConfig)
+
+	dtoResp, err := api.bankingGetStatement(context.Background(), AccountStatementRequest{
+		AccountNumber: "0201245680",
+		StartDate:     "2016-09-01",
+		EndDate:       "2016-09-01",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "2016-09-01", dtoResp.StartDate)
+
+	wantSign, _, err := GenerateSignature(givenConfig.APISecret, http.MethodGet,
+		"/banking/v3/corporates/BCAAPI2016/accounts/0201245680/statements?StartDate=2016-09-01&EndDate=2016-09-01",
+		"lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS", "", gotTimestamp)
+	require.NoError(t, err)
+	require.Equal(t, "/banking/v3/corporates/BCAAPI2016/accounts/0201245680/statements?EndDate=2016-09-01&StartDate=2016-09-01", gotPath)
+	require.Equal(t, wantSign, gotSign)
+}
+
+func Test_api_call_classifyResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantClass ErrorClass
+	}{
+		{name: "success", status: http.StatusOK, body: `{"StartDate":"2016-09-01"}`, wantClass: ErrorClassUnknown},
+		{name: "BCA error", status: http.StatusBadRequest, body: `{"ErrorCode":"ESB-14-007","ErrorMessage":{"Indonesian":"Parameter tidak valid","English":"Invalid parameter"}}`, wantClass: ErrorClassAPI},
+		{name: "gateway HTML page", status: http.StatusBadGateway, body: `<html><body>502 Bad Gateway</body></html>`, wantClass: ErrorClassGateway},
+		{name: "unauthorized empty body", status: http.StatusUnauthorized, body: ``, wantClass: ErrorClassGateway},
+		{name: "success non-JSON body", status: http.StatusOK, body: `OK`, wantClass: ErrorClassDecode},
+		{name: "success empty body", status: http.StatusOK, body: ``, wantClass: ErrorClassDecode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/oauth/token" {
+					_, _ = w.Write([]byte(`{"access_token":"lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS","expires_in":3600}`))
+					return
+				}
+				w.Header().Set("X-Foo", "bar")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			api := newAPI(Config{URL: srv.URL, CorporateID: "BCAAPI2016"})
+			_, err := api.bankingGetStatement(context.Background(), AccountStatementRequest{AccountNumber: "0201245680"})
+
+			require.Equal(t, tt.wantClass, ClassOf(err))
+			switch err := errors.Cause(err).(type) {
+			case *GatewayError:
+				require.Equal(t, tt.status, err.HTTPStatus)
+				require.Equal(t, tt.body, string(err.Body))
+				require.Equal(t, "bar", err.Header.Get("X-Foo"))
+			case *APIError:
+				require.Equal(t, tt.body, string(err.Body))
+				require.Equal(t, "GET /banking/v3/corporates/BCAAPI2016/accounts/0201245680/statements", err.Endpoint)
+			case *DecodeError:
+				require.Equal(t, tt.body, string(err.Body))
+			}
+		})
+	}
+
+	t.Run("transport error", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		api := newAPI(Config{URL: srv.URL})
+		_, err := api.postGetToken(context.Background())
+		require.Equal(t, ErrorClassTransport, ClassOf(err))
+	})
+}
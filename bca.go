@@ -4,8 +4,6 @@ import (
 	"context"
 	"os"
 
-	"github.com/avast/retry-go"
-	"github.com/juju/errors"
 	bcaCtx "github.com/purwaren/bca-api/context"
 	"github.com/purwaren/bca-api/logger"
 	"go.uber.org/zap"
@@ -19,8 +17,6 @@ type BCA struct {
 	config Config
 }
 
-const maxRetryAttempts uint = 2
-
 // New return new instance of BCA
 func New(config Config) *BCA {
 	bca := BCA{
@@ -50,38 +46,8 @@ func New(config Config) *BCA {
 	return &bca
 }
 
-var errESB14009 = errors.New("Auth err from BCA API (ESB-14-009)")
-
-func errorIfErrCodeESB14009(dtoError Error) error {
-	if dtoError.ErrorCode == "ESB-14-009" {
-		return errESB14009
-	}
-	return nil
-}
-
-func (b *BCA) retryDecision(ctx context.Context) func(err error) bool {
-	return func(err error) bool {
-		return err == errESB14009
-	}
-}
-
-func (b *BCA) retryOptions(ctx context.Context) []retry.Option {
-	return []retry.Option{
-		retry.Attempts(maxRetryAttempts),
-		retry.RetryIf(b.retryDecision(ctx)),
-		retry.OnRetry(func(n uint, err error) {
-			b.log(ctx).Infof("=== START ON RETRY === [Attempts: %d Err: %+v]", n, err)
-			_, err = b.DoAuthentication(ctx)
-			if err != nil {
-				b.log(ctx).Error(err)
-			}
-			b.log(ctx).Infof("=== END ON RETRY ===")
-		}),
-	}
-}
-
 // === misc func ===
 
 func (b *BCA) log(ctx context.Context) *zap.SugaredLogger {
-	return logger.Logger(bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.bcaSessID)))
+	return logger.Logger(bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID())))
 }
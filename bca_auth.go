@@ -7,20 +7,19 @@ import (
 	bcaCtx "github.com/purwaren/bca-api/context"
 )
 
-// DoAuthentication authenticate using OAuth2
+// DoAuthentication authenticate using OAuth2.
+// It is not required to be called explicitly, access token is fetched before the first call and refreshed ahead of its expiry.
 func (b *BCA) DoAuthentication(ctx context.Context) (*AuthToken, error) {
-	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.bcaSessID))
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
 
 	b.log(ctx).Info("=== START DO_AUTH ===")
 
-	dtoResp, err := b.api.postGetToken(ctx)
+	dtoResp, err := b.api.tokens.refresh(ctx)
 	if err != nil {
 		b.log(ctx).Error(errors.Details(err))
 		return nil, errors.Trace(err)
 	}
 
-	b.api.setAccessToken(dtoResp.AccessToken)
-
 	b.log(ctx).Info("=== END DO_AUTH ===")
 
 	return dtoResp, nil
@@ -2,25 +2,79 @@ package bca
 
 import (
 	"context"
+	"strings"
 
 	"github.com/avast/retry-go"
 	"github.com/juju/errors"
 	bcaCtx "github.com/purwaren/bca-api/context"
 )
 
-// BankingGetBalance get account balance
+// BankingGetBalance get balance of one or more accounts.
+// Accounts are requested in chunks of MaxBalanceInfoAccounts and the results are merged,
+// use AccountErrors of the response to inspect accounts which are failed.
+// Accounts of failed chunk are listed in AccountErrors with the error of the chunk,
+// error is returned only when every chunk fails.
 func (b *BCA) BankingGetBalance(ctx context.Context, dtoReq BalanceInfoRequest) (dtoResp *BalanceInfoResponse, err error) {
-	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.bcaSessID))
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
 
 	b.log(ctx).Info("=== START BANKING GET_BALANCE ===")
 	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
 
-	retryOpts := b.retryOptions(ctx)
-	err = retry.Do(func() error {
-		if dtoResp, err = b.api.bankingGetBalance(ctx, dtoReq); err != nil {
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	dtoResp = &BalanceInfoResponse{}
+	var chunkErr error
+	succeeded := false
+	for _, chunk := range chunkAccountNumbers(dtoReq.accountNumbers(), MaxBalanceInfoAccounts) {
+		chunkReq := BalanceInfoRequest{AccountNumbers: chunk}
+
+		var chunkResp *BalanceInfoResponse
+		retryOpts := b.retryOptions(ctx, true)
+		err = retry.Do(func() error {
+			chunkResp, err = b.api.bankingGetBalance(ctx, chunkReq)
 			return err
+		}, retryOpts...)
+
+		if err != nil {
+			b.log(ctx).Error(errors.Details(err))
+			chunkErr = errors.Trace(err)
+			dtoResp.AccountDetailDataFailed = append(dtoResp.AccountDetailDataFailed, failedAccountBalances(chunk, err)...)
+			continue
 		}
-		return errorIfErrCodeESB14009(dtoResp.Error)
+		succeeded = true
+
+		dtoResp.AccountDetailDataSuccess = append(dtoResp.AccountDetailDataSuccess, chunkResp.AccountDetailDataSuccess...)
+		dtoResp.AccountDetailDataFailed = append(dtoResp.AccountDetailDataFailed, chunkResp.AccountDetailDataFailed...)
+	}
+	if !succeeded {
+		return nil, chunkErr
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END BANKING GET_BALANCE ===")
+
+	return dtoResp, nil
+}
+
+// BankingGetStatement get account statement within StartDate and EndDate (yyyy-MM-dd)
+func (b *BCA) BankingGetStatement(ctx context.Context, dtoReq AccountStatementRequest) (dtoResp *AccountStatementResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.log(ctx).Info("=== START BANKING GET_STATEMENT ===")
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, true)
+	err = retry.Do(func() error {
+		dtoResp, err = b.api.bankingGetStatement(ctx, dtoReq)
+		return err
 	}, retryOpts...)
 
 	if err != nil {
@@ -29,32 +83,80 @@ func (b *BCA) BankingGetBalance(ctx context.Context, dtoReq BalanceInfoRequest)
 	}
 
 	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
-	b.log(ctx).Info("=== END BANKING GET_BALANCE ===")
+	b.log(ctx).Info("=== END BANKING GET_STATEMENT ===")
 
 	return dtoResp, nil
 }
 
-// BankingFundTransfer fund transfer to another BCA account
+// BankingFundTransfer fund transfer to another BCA account.
+// Empty TransactionID and TransactionDate are generated by Config.TransactionIDGenerator and returned in the response.
 func (b *BCA) BankingFundTransfer(ctx context.Context, dtoReq FundTransferRequest) (dtoResp *FundTransferResponse, err error) {
-	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.bcaSessID))
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
 
 	dtoReq.CorporateID = b.config.CorporateID
 
 	b.log(ctx).Info("=== START BANKING FUND_TRANSFER ===")
+
+	// validated before TransactionID is generated, so invalid request does not consume a sequence number
+	validatedReq := dtoReq
+	validatedReq.TransactionID, validatedReq.TransactionDate = placeholderTransactionID(dtoReq.TransactionID, dtoReq.TransactionDate)
+	if err = validateRequest(validatedReq); err != nil {
+		b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	if err = b.config.TransactionIDGenerator.fill(ctx, &dtoReq.TransactionID, &dtoReq.TransactionDate); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
 	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
 
-	retryOpts := b.retryOptions(ctx)
-	err = retry.Do(func() error {
-		if dtoResp, err = b.api.bankingPostFundTransfer(ctx, dtoReq); err != nil {
-			return err
-		}
-		return errorIfErrCodeESB14009(dtoResp.Error)
-	}, retryOpts...)
+	ledgerEntry := LedgerEntry{
+		LedgerKey: LedgerKey{
+			CorporateID:     dtoReq.CorporateID,
+			TransactionDate: dtoReq.TransactionDate,
+			TransactionID:   dtoReq.TransactionID,
+		},
+		TransferType:             TransferTypeBCA,
+		ReferenceID:              dtoReq.ReferenceID,
+		SourceAccountNumber:      dtoReq.SourceAccountNumber,
+		BeneficiaryAccountNumber: dtoReq.BeneficiaryAccountNumber,
+		CurrencyCode:             dtoReq.CurrencyCode,
+		Amount:                   dtoReq.Amount,
+	}
+	if err = b.beginTransfer(ctx, ledgerEntry); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	statusReq := TransferStatusRequest{
+		TransactionID:   dtoReq.TransactionID,
+		TransactionDate: dtoReq.TransactionDate,
+		TransferType:    TransferTypeBCA,
+	}
+	statusResp, err := b.transfer(ctx, statusReq, func() (err error) {
+		dtoResp, err = b.api.bankingPostFundTransfer(ctx, dtoReq)
+		return err
+	})
+	b.finishTransfer(ctx, ledgerEntry.LedgerKey, err)
 
 	if err != nil {
 		b.log(ctx).Error(errors.Details(err))
 		return nil, errors.Trace(err)
 	}
+	if statusResp != nil {
+		dtoResp = &FundTransferResponse{
+			TransactionID:   statusResp.TransactionID,
+			TransactionDate: statusResp.TransactionDate,
+			ReferenceID:     statusResp.ReferenceID,
+			Status:          statusResp.Status,
+		}
+	}
+	if dtoResp.TransactionID == "" {
+		dtoResp.TransactionID, dtoResp.TransactionDate = dtoReq.TransactionID, dtoReq.TransactionDate
+	}
 
 	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
 	b.log(ctx).Info("=== END BANKING FUND_TRANSFER ===")
@@ -62,19 +164,163 @@ func (b *BCA) BankingFundTransfer(ctx context.Context, dtoReq FundTransferReques
 	return dtoResp, nil
 }
 
-// BankingFundTransferDomestic fund transfer to domestic bank account
+// BankingFundTransferDomestic fund transfer to domestic bank account.
+// Empty TransactionID and TransactionDate are generated by Config.TransactionIDGenerator and returned in the response.
+// With Config.VerifyDomesticBeneficiary, the transfer is aborted when BeneficiaryName does not match the account name.
 func (b *BCA) BankingFundTransferDomestic(ctx context.Context, dtoReq FundTransferDomesticRequest) (dtoResp *FundTransferDomesticResponse, err error) {
-	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.bcaSessID))
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
 
 	b.log(ctx).Info("=== START BANKING FUND_TRANSFER_DOMESTIC ===")
+
+	// validated and verified before TransactionID is generated, so rejected request does not consume a sequence number
+	validatedReq := dtoReq
+	validatedReq.TransactionID, validatedReq.TransactionDate = placeholderTransactionID(dtoReq.TransactionID, dtoReq.TransactionDate)
+	if err = validateRequest(validatedReq); err != nil {
+		b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	if b.config.VerifyDomesticBeneficiary {
+		if err = b.verifyDomesticBeneficiary(ctx, dtoReq); err != nil {
+			b.log(ctx).Error(errors.Details(err))
+			return nil, errors.Trace(err)
+		}
+	}
+
+	if err = b.config.TransactionIDGenerator.fill(ctx, &dtoReq.TransactionID, &dtoReq.TransactionDate); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	ledgerEntry := LedgerEntry{
+		LedgerKey: LedgerKey{
+			CorporateID:     b.config.CorporateID,
+			TransactionDate: dtoReq.TransactionDate,
+			TransactionID:   dtoReq.TransactionID,
+		},
+		TransferType:             dtoReq.TransferType,
+		ReferenceID:              dtoReq.ReferenceID,
+		SourceAccountNumber:      dtoReq.SourceAccountNumber,
+		BeneficiaryAccountNumber: dtoReq.BeneficiaryAccountNumber,
+		BeneficiaryBankCode:      dtoReq.BeneficiaryBankCode,
+		CurrencyCode:             dtoReq.CurrencyCode,
+		Amount:                   dtoReq.Amount,
+	}
+	if err = b.beginTransfer(ctx, ledgerEntry); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	statusReq := TransferStatusRequest{
+		TransactionID:   dtoReq.TransactionID,
+		TransactionDate: dtoReq.TransactionDate,
+		TransferType:    dtoReq.TransferType,
+	}
+	statusResp, err := b.transfer(ctx, statusReq, func() (err error) {
+		dtoResp, err = b.api.bankingPostFundTransferDomestic(ctx, dtoReq)
+		return err
+	})
+	b.finishTransfer(ctx, ledgerEntry.LedgerKey, err)
+
+	if err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+	if statusResp != nil {
+		dtoResp = &FundTransferDomesticResponse{
+			TransactionID:   statusResp.TransactionID,
+			TransactionDate: statusResp.TransactionDate,
+			ReferenceID:     statusResp.ReferenceID,
+			PPUNumber:       statusResp.PPUNumber,
+			Status:          statusResp.Status,
+		}
+	}
+	if dtoResp.TransactionID == "" {
+		dtoResp.TransactionID, dtoResp.TransactionDate = dtoReq.TransactionID, dtoReq.TransactionDate
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END BANKING FUND_TRANSFER_DOMESTIC ===")
+
+	return dtoResp, nil
+}
+
+// BankingGetDomesticAccount inquiry name of domestic (other bank) account
+func (b *BCA) BankingGetDomesticAccount(ctx context.Context, dtoReq DomesticAccountRequest) (dtoResp *DomesticAccountResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.log(ctx).Info("=== START BANKING GET_DOMESTIC_ACCOUNT ===")
 	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
 
-	retryOpts := b.retryOptions(ctx)
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, true)
 	err = retry.Do(func() error {
-		if dtoResp, err = b.api.bankingPostFundTransferDomestic(ctx, dtoReq); err != nil {
-			return err
+		dtoResp, err = b.api.bankingGetDomesticAccount(ctx, dtoReq)
+		return err
+	}, retryOpts...)
+
+	if err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END BANKING GET_DOMESTIC_ACCOUNT ===")
+
+	return dtoResp, nil
+}
+
+// verifyDomesticBeneficiary return *BeneficiaryMismatchError if BeneficiaryName of dtoReq does not match name of the beneficiary account
+func (b *BCA) verifyDomesticBeneficiary(ctx context.Context, dtoReq FundTransferDomesticRequest) error {
+	accountResp, err := b.BankingGetDomesticAccount(ctx, DomesticAccountRequest{
+		BeneficiaryBankCode:      dtoReq.BeneficiaryBankCode,
+		BeneficiaryAccountNumber: dtoReq.BeneficiaryAccountNumber,
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	threshold := b.config.BeneficiaryNameThreshold
+	if threshold <= 0 {
+		threshold = DefaultBeneficiaryNameThreshold
+	}
+	similarity := nameSimilarity(dtoReq.BeneficiaryName, accountResp.BeneficiaryName, maxBeneficiaryNameRunes)
+	if similarity < threshold {
+		return &BeneficiaryMismatchError{
+			BeneficiaryBankCode:      dtoReq.BeneficiaryBankCode,
+			BeneficiaryAccountNumber: dtoReq.BeneficiaryAccountNumber,
+			BeneficiaryName:          dtoReq.BeneficiaryName,
+			AccountName:              accountResp.BeneficiaryName,
+			Similarity:               similarity,
+			Threshold:                threshold,
 		}
-		return errorIfErrCodeESB14009(dtoResp.Error)
+	}
+	return nil
+}
+
+// BankingGetTransferStatus inquiry status of transfer to BCA account or domestic transfer by its TransactionID and TransactionDate
+func (b *BCA) BankingGetTransferStatus(ctx context.Context, dtoReq TransferStatusRequest) (dtoResp *TransferStatusResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.log(ctx).Info("=== START BANKING GET_TRANSFER_STATUS ===")
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, true)
+	err = retry.Do(func() error {
+		dtoResp, err = b.api.bankingGetTransferStatus(ctx, dtoReq)
+		return err
 	}, retryOpts...)
 
 	if err != nil {
@@ -83,7 +329,117 @@ func (b *BCA) BankingFundTransferDomestic(ctx context.Context, dtoReq FundTransf
 	}
 
 	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
-	b.log(ctx).Info("=== END BANKING FUND_TRANSFER_DOMESTIC ===")
+	b.log(ctx).Info("=== END BANKING GET_TRANSFER_STATUS ===")
 
 	return dtoResp, nil
 }
+
+// transfer send transfer request, retrying only on refused access token.
+// In at-most-once mode, ambiguous failure is resolved by transfer status inquiry: status of the successful transfer is returned,
+// failed transfer returns ErrTransferFailed, and the transfer is resubmitted only when BCA confirms it is unknown.
+func (b *BCA) transfer(ctx context.Context, statusReq TransferStatusRequest, send func() error) (*TransferStatusResponse, error) {
+	policy := b.config.RetryPolicy.withDefaults()
+
+	for attempt := uint(1); ; attempt++ {
+		err := retry.Do(send, b.retryOptions(ctx, false)...)
+		if err == nil || !b.config.AtMostOnceTransfer || !isAmbiguousTransferError(err) {
+			return nil, err
+		}
+
+		b.log(ctx).Infof("=== AMBIGUOUS TRANSFER === [Attempts: %d Err: %+v]", attempt, err)
+
+		statusResp, statusErr := b.BankingGetTransferStatus(ctx, statusReq)
+		if statusErr == nil {
+			switch {
+			case strings.EqualFold(statusResp.Status, TransferStatusSuccess):
+				return statusResp, nil
+			case strings.EqualFold(statusResp.Status, TransferStatusFailed):
+				return nil, errors.Annotatef(ErrTransferFailed, "transfer %s on %s", statusReq.TransactionID, statusReq.TransactionDate)
+			}
+			statusErr = errors.Errorf("transfer status %q", statusResp.Status)
+		}
+		if !IsTransactionNotFound(statusErr) {
+			return nil, &AmbiguousTransferError{
+				TransactionID:   statusReq.TransactionID,
+				TransactionDate: statusReq.TransactionDate,
+				Err:             err,
+				StatusErr:       statusErr,
+			}
+		}
+		if attempt >= policy.Attempts {
+			return nil, errors.Annotatef(statusErr, "transfer is not booked after %d attempts", attempt)
+		}
+
+		b.log(ctx).Info("=== RESUBMIT TRANSFER UNKNOWN TO BCA ===")
+	}
+}
+
+// beginTransfer record transfer in Config.Ledger, if any, rejecting reused TransactionID
+func (b *BCA) beginTransfer(ctx context.Context, entry LedgerEntry) error {
+	if b.config.Ledger == nil {
+		return nil
+	}
+	return errors.Trace(b.config.Ledger.Begin(ctx, entry))
+}
+
+// finishTransfer record result of transfer in Config.Ledger, if any.
+// Transfer which BCA confirms as failed or unknown is released, so it can be sent again.
+// Failure to record is only logged as the transfer itself is already done.
+func (b *BCA) finishTransfer(ctx context.Context, key LedgerKey, err error) {
+	if b.config.Ledger == nil {
+		return
+	}
+
+	if IsTransferFailed(err) || IsTransactionNotFound(err) {
+		if err := b.config.Ledger.Release(ctx, key); err != nil {
+			b.log(ctx).Error(errors.Details(err))
+		}
+		return
+	}
+
+	state, errorCode := TransferStateSuccess, ""
+	if err != nil {
+		state = TransferStateFailed
+		if isAmbiguousTransferError(err) {
+			state = TransferStateUnknown
+		}
+		if apiErr, ok := AsAPIError(err); ok {
+			errorCode = apiErr.ErrorCode
+		}
+	}
+
+	if err := b.config.Ledger.Finish(ctx, key, state, errorCode); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+	}
+}
+
+// isAmbiguousTransferError report whether BCA may have booked the transfer failed with err
+func isAmbiguousTransferError(err error) bool {
+	switch ClassOf(err) {
+	case ErrorClassTransport, ErrorClassGateway, ErrorClassDecode:
+		return true
+	}
+	return false
+}
+
+// failedAccountBalances return failed balance information of accountNumbers requested in the chunk failed with err
+func failedAccountBalances(accountNumbers []string, err error) []AccountBalance {
+	message := ErrorLang{Indonesian: err.Error(), English: err.Error()}
+	if apiErr, ok := AsAPIError(err); ok {
+		message = apiErr.ErrorMessage
+	}
+
+	failed := make([]AccountBalance, 0, len(accountNumbers))
+	for _, accountNumber := range accountNumbers {
+		failed = append(failed, AccountBalance{AccountNumber: accountNumber, Indonesian: message.Indonesian, English: message.English})
+	}
+	return failed
+}
+
+func chunkAccountNumbers(accountNumbers []string, size int) [][]string {
+	var chunks [][]string
+	for size < len(accountNumbers) {
+		accountNumbers, chunks = accountNumbers[size:], append(chunks, accountNumbers[:size])
+	}
+	return append(chunks, accountNumbers)
+}
@@ -2,11 +2,21 @@ package bca_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"path"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/juju/errors"
 	"github.com/purwaren/bca-api"
+	"github.com/purwaren/bca-api/bcamock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,6 +49,34 @@ func TestBCA_Banking_integration(t *testing.T) {
 		require.Empty(t, dtoResp.Error)
 	})
 
+	t.Run("BankingGetStatement", func(t *testing.T) {
+		givenConfig := bca.Config{
+			URL:          os.Getenv("URL"),
+			ClientID:     os.Getenv("CLIENT_ID"),
+			ClientSecret: os.Getenv("CLIENT_SECRET"),
+
+			CorporateID: os.Getenv("CORPORATE_ID"),
+
+			APIKey:    os.Getenv("API_KEY"),
+			APISecret: os.Getenv("API_SECRET"),
+
+			OriginHost: os.Getenv("ORIGIN_HOST"),
+		}
+
+		b := bca.New(givenConfig)
+
+		// resp based on sandbox doc
+		givenDtoReq := bca.AccountStatementRequest{
+			AccountNumber: "0201245680",
+			StartDate:     "2016-08-29",
+			EndDate:       "2016-09-01",
+		}
+		dtoResp, err := b.BankingGetStatement(context.Background(), givenDtoReq)
+
+		require.NoError(t, err)
+		require.Empty(t, dtoResp.Error)
+	})
+
 	t.Run("BankingFundTransfer", func(t *testing.T) {
 		givenConfig := bca.Config{
 			URL:          os.Getenv("URL"),
@@ -62,7 +100,7 @@ func TestBCA_Banking_integration(t *testing.T) {
 			TransactionDate:          time.Now().Format("2006-01-02"),
 			ReferenceID:              "12345/PO/2016",
 			CurrencyCode:             "IDR",
-			Amount:                   100000.00,
+			Amount:                   bca.NewAmount(100000, 0),
 			BeneficiaryAccountNumber: "0201245681",
 			Remark1:                  "Transfer Test",
 			Remark2:                  "Online Transfer",
@@ -101,7 +139,7 @@ func TestBCA_Banking_integration(t *testing.T) {
 			BeneficiaryAccountNumber: "0201245501",
 			BeneficiaryBankCode:      "BRONINJA",
 			BeneficiaryName:          "Tester",
-			Amount:                   100000.00,
+			Amount:                   bca.NewAmount(100000, 0),
 			TransferType:             "LLG",
 			BeneficiaryCustType:      "1",
 			BeneficiaryCustResidence: "1",
@@ -114,4 +152,336 @@ func TestBCA_Banking_integration(t *testing.T) {
 		require.NoError(t, err)
 		require.Empty(t, dtoResp.Error)
 	})
+
+	t.Run("BankingGetTransferStatus", func(t *testing.T) {
+		givenConfig := bca.Config{
+			URL:          os.Getenv("URL"),
+			ClientID:     os.Getenv("CLIENT_ID"),
+			ClientSecret: os.Getenv("CLIENT_SECRET"),
+
+			CorporateID: os.Getenv("CORPORATE_ID"),
+
+			APIKey:    os.Getenv("API_KEY"),
+			APISecret: os.Getenv("API_SECRET"),
+
+			ChannelID:    os.Getenv("CHANNEL_ID"),
+			CredentialID: os.Getenv("CREDENTIAL_ID"),
+
+			OriginHost: os.Getenv("ORIGIN_HOST"),
+		}
+
+		b := bca.New(givenConfig)
+
+		// resp based on sandbox doc
+		givenDtoReq := bca.TransferStatusRequest{
+			TransactionID:   "00000001",
+			TransactionDate: "2018-05-03",
+			TransferType:    "LLG",
+		}
+		dtoResp, err := b.BankingGetTransferStatus(context.Background(), givenDtoReq)
+
+		require.NoError(t, err)
+		require.Empty(t, dtoResp.Error)
+	})
+}
+
+func TestBCA_BankingGetBalance_multiAccount(t *testing.T) {
+	var gotAccountNumbers [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth/token" {
+			_ = json.NewEncoder(w).Encode(bca.AuthToken{AccessToken: "lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS", ExpiresIn: 3600})
+			return
+		}
+		accountNumbers := strings.Split(path.Base(r.URL.Path), ",")
+		gotAccountNumbers = append(gotAccountNumbers, accountNumbers)
+
+		var dtoResp bca.BalanceInfoResponse
+		for _, accountNumber := range accountNumbers {
+			if accountNumber == "0000000000" {
+				dtoResp.AccountDetailDataFailed = append(dtoResp.AccountDetailDataFailed, bca.AccountBalance{
+					AccountNumber: accountNumber,
+					Indonesian:    "Nomor rekening tidak valid",
+					English:       "Invalid account number",
+				})
+				continue
+			}
+			dtoResp.AccountDetailDataSuccess = append(dtoResp.AccountDetailDataSuccess, bca.AccountBalance{
+				AccountNumber: accountNumber,
+				Currency:      "IDR",
+				Balance:       bca.NewAmount(100000, 0),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(dtoResp)
+	}))
+	defer srv.Close()
+
+	b := bca.New(bca.Config{URL: srv.URL, CorporateID: "BCAAPI2016"})
+
+	givenAccountNumbers := []string{"0000000000"}
+	for i := 1; i <= 24; i++ {
+		givenAccountNumbers = append(givenAccountNumbers, fmt.Sprintf("02012456%02d", i))
+	}
+
+	dtoResp, err := b.BankingGetBalance(context.Background(), bca.BalanceInfoRequest{
+		AccountNumber:  "0201245601, 0201245602",
+		AccountNumbers: givenAccountNumbers,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, gotAccountNumbers, 2)
+	require.Len(t, gotAccountNumbers[0], bca.MaxBalanceInfoAccounts)
+	require.Len(t, gotAccountNumbers[1], 5)
+	require.Len(t, dtoResp.AccountDetailDataSuccess, 24)
+
+	accountErrs := dtoResp.AccountErrors()
+	require.Len(t, accountErrs, 1)
+	require.Equal(t, "0000000000", accountErrs[0].AccountNumber)
+	require.Equal(t, "Invalid account number", accountErrs[0].ErrorMessage.English)
+}
+
+func TestBCA_BankingGetBalance_failedChunk(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth/token" {
+			_ = json.NewEncoder(w).Encode(bca.AuthToken{AccessToken: "lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS", ExpiresIn: 3600})
+			return
+		}
+		accountNumbers := strings.Split(path.Base(r.URL.Path), ",")
+		if accountNumbers[0] != "0201245601" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"ErrorCode":"ESB-82-005","ErrorMessage":{"Indonesian":"Rekening tidak valid","English":"Invalid account"}}`))
+			return
+		}
+
+		var dtoResp bca.BalanceInfoResponse
+		for _, accountNumber := range accountNumbers {
+			dtoResp.AccountDetailDataSuccess = append(dtoResp.AccountDetailDataSuccess, bca.AccountBalance{
+				AccountNumber: accountNumber,
+				Currency:      "IDR",
+				Balance:       bca.NewAmount(100000, 0),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(dtoResp)
+	}))
+	defer srv.Close()
+
+	b := bca.New(bca.Config{URL: srv.URL, CorporateID: "BCAAPI2016"})
+
+	var givenAccountNumbers []string
+	for i := 1; i <= 25; i++ {
+		givenAccountNumbers = append(givenAccountNumbers, fmt.Sprintf("02012456%02d", i))
+	}
+
+	dtoResp, err := b.BankingGetBalance(context.Background(), bca.BalanceInfoRequest{AccountNumbers: givenAccountNumbers})
+	require.NoError(t, err)
+	require.Len(t, dtoResp.AccountDetailDataSuccess, bca.MaxBalanceInfoAccounts)
+
+	accountErrs := dtoResp.AccountErrors()
+	require.Len(t, accountErrs, 5)
+	require.Equal(t, "0201245621", accountErrs[0].AccountNumber)
+	require.Equal(t, "Invalid account", accountErrs[0].ErrorMessage.English)
+
+	t.Run("every chunk fails", func(t *testing.T) {
+		_, err := b.BankingGetBalance(context.Background(), bca.BalanceInfoRequest{AccountNumbers: givenAccountNumbers[bca.MaxBalanceInfoAccounts:]})
+		apiErr, ok := bca.AsAPIError(err)
+		require.True(t, ok, "%+v", err)
+		require.Equal(t, bca.ErrCodeInvalidAccount, apiErr.ErrorCode)
+	})
+}
+
+func TestBCA_BankingFundTransfer_atMostOnce(t *testing.T) {
+	notFoundResp := `{"ErrorCode":"ESB-82-023","ErrorMessage":{"Indonesian":"Transaksi tidak ditemukan","English":"Transaction not found"}}`
+	ambiguous := func(t *testing.T, err error) {
+		_, ok := errors.Cause(err).(*bca.AmbiguousTransferError)
+		require.True(t, ok, "%+v", err)
+		require.Equal(t, bca.ErrorClassGateway, bca.ClassOf(err))
+	}
+
+	tests := []struct {
+		name          string
+		statusResp    string
+		statusCode    int
+		attempts      uint
+		checkErr      func(t *testing.T, err error)
+		wantStatus    string
+		wantState     bca.TransferState // empty when the entry is released
+		wantPostCount int
+	}{
+		{name: "transfer unknown is resubmitted", statusCode: http.StatusNotFound, statusResp: notFoundResp,
+			wantStatus: "Success", wantState: bca.TransferStateSuccess, wantPostCount: 2},
+		{name: "transfer unknown after last attempt is released", statusCode: http.StatusNotFound, statusResp: notFoundResp, attempts: 1,
+			checkErr: func(t *testing.T, err error) {
+				require.True(t, bca.IsTransactionNotFound(err), "%+v", err)
+			},
+			wantPostCount: 1},
+		{name: "booked transfer is not resubmitted", statusCode: http.StatusOK,
+			statusResp: `{"TransactionID":"00000001","TransactionDate":"2016-01-30","ReferenceID":"12345/PO/2016","Status":"Success"}`,
+			wantStatus: "Success", wantState: bca.TransferStateSuccess, wantPostCount: 1},
+		{name: "failed transfer is released", statusCode: http.StatusOK,
+			statusResp: `{"TransactionID":"00000001","TransactionDate":"2016-01-30","ReferenceID":"12345/PO/2016","Status":"Failed"}`,
+			checkErr: func(t *testing.T, err error) {
+				require.True(t, bca.IsTransferFailed(err), "%+v", err)
+			},
+			wantPostCount: 1},
+		{name: "unrecognized status is ambiguous", statusCode: http.StatusOK,
+			statusResp: `{"TransactionID":"00000001","TransactionDate":"2016-01-30","ReferenceID":"12345/PO/2016","Status":"Pending"}`,
+			checkErr:   ambiguous, wantState: bca.TransferStateUnknown, wantPostCount: 1},
+		{name: "status inquiry failure is ambiguous", statusCode: http.StatusBadGateway,
+			statusResp: `<html><body>502 Bad Gateway</body></html>`,
+			checkErr:   ambiguous, wantState: bca.TransferStateUnknown, wantPostCount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// recorded by the handler, asserted on the test goroutine
+			var postCount int32
+			statusQueries := make(chan url.Values, 10)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/api/oauth/token":
+					_ = json.NewEncoder(w).Encode(bca.AuthToken{AccessToken: "lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS", ExpiresIn: 3600})
+				case r.URL.Path == "/banking/corporates/transfers/v2/status/00000001":
+					statusQueries <- r.URL.Query()
+					w.WriteHeader(tt.statusCode)
+					_, _ = w.Write([]byte(tt.statusResp))
+				case r.URL.Path == "/banking/corporates/transfers":
+					if atomic.AddInt32(&postCount, 1) == 1 {
+						w.WriteHeader(http.StatusGatewayTimeout)
+						return
+					}
+					_, _ = w.Write([]byte(`{"TransactionID":"00000001","TransactionDate":"2016-01-30","ReferenceID":"12345/PO/2016","Status":"Success"}`))
+				}
+			}))
+			defer srv.Close()
+
+			ledger := bca.NewMemoryLedger()
+			b := bca.New(bca.Config{
+				URL:                srv.URL,
+				CorporateID:        "BCAAPI2016",
+				AtMostOnceTransfer: true,
+				RetryPolicy:        bca.RetryPolicy{Attempts: tt.attempts, InitialBackoff: time.Millisecond},
+				Ledger:             ledger,
+			})
+
+			dtoResp, err := b.BankingFundTransfer(context.Background(), bca.FundTransferRequest{
+				SourceAccountNumber:      "0201245680",
+				TransactionID:            "00000001",
+				TransactionDate:          "2016-01-30",
+				ReferenceID:              "12345/PO/2016",
+				CurrencyCode:             "IDR",
+				Amount:                   bca.NewAmount(100000, 0),
+				BeneficiaryAccountNumber: "0201245681",
+			})
+			require.Equal(t, tt.wantPostCount, int(atomic.LoadInt32(&postCount)))
+			close(statusQueries)
+			require.NotEmpty(t, statusQueries)
+			for query := range statusQueries {
+				require.Equal(t, "2016-01-30", query.Get("TransactionDate"))
+				require.Equal(t, bca.TransferTypeBCA, query.Get("TransferType"))
+			}
+
+			entry, ledgerErr := ledger.Get(context.Background(), bca.LedgerKey{CorporateID: "BCAAPI2016", TransactionDate: "2016-01-30", TransactionID: "00000001"})
+			require.NoError(t, ledgerErr)
+			if tt.wantState == "" {
+				require.Nil(t, entry)
+			} else {
+				require.Equal(t, tt.wantState, entry.State)
+			}
+
+			if tt.checkErr != nil {
+				tt.checkErr(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, dtoResp.Status)
+		})
+	}
+}
+
+func TestBCA_BankingFundTransferDomestic_verifyBeneficiary(t *testing.T) {
+	srv := bcamock.NewServer(bcamock.Config{})
+	defer srv.Close()
+	srv.AddAccount(bcamock.Account{AccountNumber: "0201245680", Balance: bca.NewAmount(1000000, 0)})
+	srv.AddDomesticAccount("BRONINJA", "0201245501", "TESTER")
+
+	config := srv.ClientConfig()
+	config.VerifyDomesticBeneficiary = true
+	b := bca.New(config)
+	ctx := context.Background()
+
+	dtoResp, err := b.BankingGetDomesticAccount(ctx, bca.DomesticAccountRequest{BeneficiaryBankCode: "BRONINJA", BeneficiaryAccountNumber: "0201245501"})
+	require.NoError(t, err)
+	require.Equal(t, "TESTER", dtoResp.BeneficiaryName)
+
+	transfer := func(b *bca.BCA, transactionID, beneficiaryName string) error {
+		_, err := b.BankingFundTransferDomestic(ctx, bca.FundTransferDomesticRequest{
+			TransactionID:            transactionID,
+			TransactionDate:          "2016-01-30",
+			ReferenceID:              "12345/PO/2016",
+			SourceAccountNumber:      "0201245680",
+			BeneficiaryAccountNumber: "0201245501",
+			BeneficiaryBankCode:      "BRONINJA",
+			BeneficiaryName:          beneficiaryName,
+			Amount:                   bca.NewAmount(100000, 0),
+			TransferType:             bca.TransferTypeLLG,
+			BeneficiaryCustType:      "1",
+			BeneficiaryCustResidence: "1",
+			CurrencyCode:             "IDR",
+		})
+		return err
+	}
+	balance := func() bca.Amount {
+		account, _ := srv.Account("0201245680")
+		return account.Balance
+	}
+
+	require.NoError(t, transfer(b, "00000001", "Tester"))
+	require.Equal(t, bca.NewAmount(900000, 0), balance())
+
+	t.Run("mismatch is not sent", func(t *testing.T) {
+		err := transfer(b, "00000002", "Budi Santoso")
+		require.Equal(t, bca.ErrorClassBeneficiaryMismatch, bca.ClassOf(err))
+		mismatchErr, ok := errors.Cause(err).(*bca.BeneficiaryMismatchError)
+		require.True(t, ok)
+		require.Equal(t, "TESTER", mismatchErr.AccountName)
+		require.Equal(t, bca.DefaultBeneficiaryNameThreshold, mismatchErr.Threshold)
+		require.Equal(t, bca.NewAmount(900000, 0), balance())
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		config := config
+		config.BeneficiaryNameThreshold = 0.5
+		require.NoError(t, transfer(bca.New(config), "00000003", "Testor"))
+		require.Equal(t, bca.NewAmount(800000, 0), balance())
+	})
+
+	t.Run("word order", func(t *testing.T) {
+		srv.AddDomesticAccount("BRONINJA", "0201245501", "BUDI SANTOSO")
+		defer srv.AddDomesticAccount("BRONINJA", "0201245501", "TESTER")
+
+		require.NoError(t, transfer(b, "00000005", "Santoso Budi"))
+		require.Equal(t, bca.NewAmount(700000, 0), balance())
+	})
+
+	t.Run("empty account name is not sent", func(t *testing.T) {
+		srv.AddDomesticAccount("BRONINJA", "0201245501", "")
+		defer srv.AddDomesticAccount("BRONINJA", "0201245501", "TESTER")
+
+		err := transfer(b, "00000006", "Tester")
+		require.Equal(t, bca.ErrorClassBeneficiaryMismatch, bca.ClassOf(err))
+		require.Equal(t, bca.NewAmount(700000, 0), balance())
+	})
+
+	t.Run("unknown account is not sent", func(t *testing.T) {
+		srv2 := bcamock.NewServer(bcamock.Config{})
+		defer srv2.Close()
+		srv2.AddAccount(bcamock.Account{AccountNumber: "0201245680", Balance: bca.NewAmount(1000000, 0)})
+		config := srv2.ClientConfig()
+		config.VerifyDomesticBeneficiary = true
+
+		err := transfer(bca.New(config), "00000004", "Tester")
+		apiErr, ok := bca.AsAPIError(err)
+		require.True(t, ok)
+		require.Equal(t, bca.ErrCodeInvalidAccount, apiErr.ErrorCode)
+		account, _ := srv2.Account("0201245680")
+		require.Equal(t, bca.NewAmount(1000000, 0), account.Balance)
+	})
 }
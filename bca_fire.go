@@ -8,19 +8,25 @@ import (
 	bcaCtx "github.com/purwaren/bca-api/context"
 )
 
-// FireInquiryAccount inquiry BCA’s Account name or Other Bank Switching’s Account
+// FireInquiryAccount inquiry BCA’s Account name or Other Bank Switching’s Account.
+// Empty Authentication of FIRe requests is filled from Config.Fire.
 func (b *BCA) FireInquiryAccount(ctx context.Context, dtoReq InquiryAccountRequest) (dtoResp *InquiryAccountResponse, err error) {
-	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.bcaSessID))
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.config.Fire.fill(&dtoReq.Authentication)
 
 	b.log(ctx).Info("=== START FIRE INQUIRY_ACCOUNT ===")
 	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
 
-	retryOpts := b.retryOptions(ctx)
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, true)
 	err = retry.Do(func() error {
-		if dtoResp, err = b.api.firePostInquiryAccount(ctx, dtoReq); err != nil {
-			return err
-		}
-		return errorIfErrCodeESB14009(dtoResp.Error)
+		dtoResp, err = b.api.firePostInquiryAccount(ctx, dtoReq)
+		return err
 	}, retryOpts...)
 
 	if err != nil {
@@ -33,3 +39,191 @@ func (b *BCA) FireInquiryAccount(ctx context.Context, dtoReq InquiryAccountReque
 
 	return dtoResp, nil
 }
+
+// FireTransferToAccount transfer fund of remittance to BCA account or other bank account.
+// Like other transfers, it is retried only on refused access token.
+func (b *BCA) FireTransferToAccount(ctx context.Context, dtoReq TransferToAccountRequest) (dtoResp *TransferToAccountResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.config.Fire.fill(&dtoReq.Authentication)
+
+	b.log(ctx).Info("=== START FIRE TRANSFER_TO_ACCOUNT ===")
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, false)
+	err = retry.Do(func() error {
+		dtoResp, err = b.api.firePostTransferToAccount(ctx, dtoReq)
+		return err
+	}, retryOpts...)
+
+	if err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END FIRE TRANSFER_TO_ACCOUNT ===")
+
+	return dtoResp, nil
+}
+
+// FireCashTransfer transfer fund of remittance to be picked up in cash at BCA branch by the beneficiary answering the secret question.
+// Like other transfers, it is retried only on refused access token.
+func (b *BCA) FireCashTransfer(ctx context.Context, dtoReq CashTransferRequest) (dtoResp *CashTransferResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.config.Fire.fill(&dtoReq.Authentication)
+
+	b.log(ctx).Info("=== START FIRE CASH_TRANSFER ===")
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, false)
+	err = retry.Do(func() error {
+		dtoResp, err = b.api.firePostCashTransfer(ctx, dtoReq)
+		return err
+	}, retryOpts...)
+
+	if err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END FIRE CASH_TRANSFER ===")
+
+	return dtoResp, nil
+}
+
+// FireAmendCashTransfer amend beneficiary and secret question of cash transfer which is not picked up yet
+func (b *BCA) FireAmendCashTransfer(ctx context.Context, dtoReq AmendCashTransferRequest) (dtoResp *AmendCashTransferResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.config.Fire.fill(&dtoReq.Authentication)
+
+	b.log(ctx).Info("=== START FIRE AMEND_CASH_TRANSFER ===")
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, false)
+	err = retry.Do(func() error {
+		dtoResp, err = b.api.firePutAmendCashTransfer(ctx, dtoReq)
+		return err
+	}, retryOpts...)
+
+	if err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END FIRE AMEND_CASH_TRANSFER ===")
+
+	return dtoResp, nil
+}
+
+// FireCancelCashTransfer cancel cash transfer which is not picked up yet
+func (b *BCA) FireCancelCashTransfer(ctx context.Context, dtoReq CancelCashTransferRequest) (dtoResp *CancelCashTransferResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.config.Fire.fill(&dtoReq.Authentication)
+
+	b.log(ctx).Info("=== START FIRE CANCEL_CASH_TRANSFER ===")
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, false)
+	err = retry.Do(func() error {
+		dtoResp, err = b.api.firePutCancelCashTransfer(ctx, dtoReq)
+		return err
+	}, retryOpts...)
+
+	if err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END FIRE CANCEL_CASH_TRANSFER ===")
+
+	return dtoResp, nil
+}
+
+// FireInquiryTransaction inquire FIRe transaction by its ReferenceNumber or FormNumber, Status of the response is status of the transaction
+func (b *BCA) FireInquiryTransaction(ctx context.Context, dtoReq InquiryTransactionRequest) (dtoResp *InquiryTransactionResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.config.Fire.fill(&dtoReq.Authentication)
+
+	b.log(ctx).Info("=== START FIRE INQUIRY_TRANSACTION ===")
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, true)
+	err = retry.Do(func() error {
+		dtoResp, err = b.api.firePostInquiryTransaction(ctx, dtoReq)
+		return err
+	}, retryOpts...)
+
+	if err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END FIRE INQUIRY_TRANSACTION ===")
+
+	return dtoResp, nil
+}
+
+// FireInquiryBalance inquire balance of FIRe prefunded account
+func (b *BCA) FireInquiryBalance(ctx context.Context, dtoReq InquiryBalanceRequest) (dtoResp *InquiryBalanceResponse, err error) {
+	ctx = bcaCtx.With(ctx, bcaCtx.BCASessID(b.api.sessID()))
+
+	b.config.Fire.fill(&dtoReq.Authentication)
+
+	b.log(ctx).Info("=== START FIRE INQUIRY_BALANCE ===")
+	b.log(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	if err = validateRequest(dtoReq); err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	retryOpts := b.retryOptions(ctx, true)
+	err = retry.Do(func() error {
+		dtoResp, err = b.api.firePostInquiryBalance(ctx, dtoReq)
+		return err
+	}, retryOpts...)
+
+	if err != nil {
+		b.log(ctx).Error(errors.Details(err))
+		return nil, errors.Trace(err)
+	}
+
+	b.log(ctx).Infof("RESPONSE: %+v", dtoResp)
+	b.log(ctx).Info("=== END FIRE INQUIRY_BALANCE ===")
+
+	return dtoResp, nil
+}
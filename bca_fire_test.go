@@ -1,11 +1,18 @@
 package bca_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/purwaren/bca-api"
+	"github.com/purwaren/bca-api/bcamock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,4 +56,413 @@ func TestBCA_Fire_integration(t *testing.T) {
 		require.Empty(t, dtoResp.Error)
 	})
 
+	t.Run("FireTransferToAccount", func(t *testing.T) {
+		givenConfig := bca.Config{
+			URL:          os.Getenv("URL"),
+			ClientID:     os.Getenv("CLIENT_ID"),
+			ClientSecret: os.Getenv("CLIENT_SECRET"),
+
+			CorporateID: os.Getenv("CORPORATE_ID"),
+
+			APIKey:    os.Getenv("API_KEY"),
+			APISecret: os.Getenv("API_SECRET"),
+
+			OriginHost: os.Getenv("ORIGIN_HOST"),
+		}
+
+		b := bca.New(givenConfig)
+		// resp based on sandbox doc
+		dtoResp, err := b.FireTransferToAccount(context.Background(), givenTransferToAccountRequest("20170105001"))
+
+		require.NoError(t, err)
+		require.Empty(t, dtoResp.Error)
+	})
+
+	t.Run("FireCashTransfer", func(t *testing.T) {
+		givenConfig := bca.Config{
+			URL:          os.Getenv("URL"),
+			ClientID:     os.Getenv("CLIENT_ID"),
+			ClientSecret: os.Getenv("CLIENT_SECRET"),
+
+			CorporateID: os.Getenv("CORPORATE_ID"),
+
+			APIKey:    os.Getenv("API_KEY"),
+			APISecret: os.Getenv("API_SECRET"),
+
+			OriginHost: os.Getenv("ORIGIN_HOST"),
+		}
+
+		b := bca.New(givenConfig)
+		// resp based on sandbox doc
+		dtoResp, err := b.FireCashTransfer(context.Background(), givenCashTransferRequest("20170105002"))
+
+		require.NoError(t, err)
+		require.Empty(t, dtoResp.Error)
+	})
+
+}
+
+func givenTransferToAccountRequest(formNumber string) bca.TransferToAccountRequest {
+	return bca.TransferToAccountRequest{
+		Authentication: bca.Authentication{
+			CorporateID: "DUMMYI",
+			AccessCode:  "Kw5oTuF12dseSH44Y8ww",
+			BranchCode:  "BCA001",
+			UserID:      "BCAUSERID",
+			LocalID:     "40115"},
+		SenderDetails: bca.SenderDetails{
+			FirstName:            "Sender",
+			LastName:             "Name",
+			DateOfBirth:          "2000-05-20",
+			Address1:             "Sender Address",
+			City:                 "Hong Kong",
+			CountryID:            "HK",
+			Mobile:               "0000000000",
+			IdentificationType:   "0001",
+			IdentificationNumber: "A123456",
+		},
+		BeneficiaryDetails: bca.TransferToAccountRequestBeneficiaryDetails{
+			Name:          "Beneficiary Name",
+			Address1:      "Beneficiary Address",
+			City:          "Jakarta",
+			CountryID:     "ID",
+			BankCodeType:  "BIC",
+			BankCodeValue: "CENAIDJAXXX",
+			BankCountryID: "ID",
+			AccountNumber: "0106666011",
+		},
+		TransactionDetails: bca.TransferToAccountRequestTransactionDetails{
+			CurrencyID:      "IDR",
+			Amount:          bca.NewAmount(100000, 0),
+			PurposeCode:     "011",
+			Description1:    "Remittance",
+			DetailOfCharges: "SHA",
+			SourceOfFund:    "Salary",
+			FormNumber:      formNumber,
+		},
+	}
+}
+
+func TestBCA_FireTransferToAccount(t *testing.T) {
+	srv := bcamock.NewServer(bcamock.Config{})
+	defer srv.Close()
+	srv.AddAccount(bcamock.Account{AccountNumber: "0106666011", Name: "STEVEN"})
+
+	b := bca.New(srv.ClientConfig())
+
+	dtoResp, err := b.FireTransferToAccount(context.Background(), givenTransferToAccountRequest("20170105001"))
+	require.NoError(t, err)
+	require.Equal(t, "STEVEN", dtoResp.BeneficiaryDetails.ServerBeneAccountName)
+	require.Equal(t, "20170105001", dtoResp.TransactionDetails.FormNumber)
+	require.NotEmpty(t, dtoResp.TransactionDetails.ReferenceNumber)
+	require.Equal(t, bca.NewAmount(100000, 0), dtoResp.TransactionDetails.Amount)
+
+	account, _ := srv.Account("0106666011")
+	require.Equal(t, bca.NewAmount(100000, 0), account.Balance)
+
+	t.Run("duplicate FormNumber is not retried", func(t *testing.T) {
+		_, err := b.FireTransferToAccount(context.Background(), givenTransferToAccountRequest("20170105001"))
+		require.True(t, bca.IsDuplicateTransaction(err))
+
+		account, _ := srv.Account("0106666011")
+		require.Equal(t, bca.NewAmount(100000, 0), account.Balance)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		dtoReq := givenTransferToAccountRequest("20170105002")
+		dtoReq.SenderDetails.FirstName = ""
+		dtoReq.TransactionDetails.DetailOfCharges = "BEN"
+		_, err := b.FireTransferToAccount(context.Background(), dtoReq)
+		require.Equal(t, bca.ErrorClassValidation, bca.ClassOf(err))
+		require.Contains(t, err.Error(), "SenderDetails.FirstName")
+		require.Contains(t, err.Error(), "TransactionDetails.DetailOfCharges")
+	})
+}
+
+func givenCashTransferRequest(formNumber string) bca.CashTransferRequest {
+	transferReq := givenTransferToAccountRequest(formNumber)
+	return bca.CashTransferRequest{
+		Authentication: transferReq.Authentication,
+		SenderDetails:  transferReq.SenderDetails,
+		BeneficiaryDetails: bca.CashTransferRequestBeneficiaryDetails{
+			Name:                 "Beneficiary Name",
+			Address1:             "Beneficiary Address",
+			City:                 "Jakarta",
+			CountryID:            "ID",
+			IdentificationType:   "0001",
+			IdentificationNumber: "3171000000000001",
+		},
+		TransactionDetails: bca.CashTransferRequestTransactionDetails{
+			CurrencyID:      "IDR",
+			Amount:          bca.NewAmount(100000, 0),
+			PurposeCode:     "011",
+			Description1:    "Remittance",
+			DetailOfCharges: "SHA",
+			SourceOfFund:    "Salary",
+			FormNumber:      formNumber,
+			SecretQuestion:  "Mother's maiden name",
+			SecretAnswer:    "Siti",
+		},
+	}
+}
+
+func TestBCA_FireCashTransfer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bca-log")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srv := bcamock.NewServer(bcamock.Config{})
+	defer srv.Close()
+
+	config := srv.ClientConfig()
+	config.LogPath = filepath.Join(dir, "bca.log")
+	b := bca.New(config)
+	ctx := context.Background()
+	authentication := givenCashTransferRequest("").Authentication
+
+	transfer := func(formNumber string) bca.FireTransactionDetails {
+		dtoResp, err := b.FireCashTransfer(ctx, givenCashTransferRequest(formNumber))
+		require.NoError(t, err)
+		require.Equal(t, "Beneficiary Name", dtoResp.BeneficiaryDetails.Name)
+		require.Equal(t, formNumber, dtoResp.TransactionDetails.FormNumber)
+		require.NotEmpty(t, dtoResp.TransactionDetails.ReferenceNumber)
+		return dtoResp.TransactionDetails
+	}
+	amend := func(details bca.FireTransactionDetails) error {
+		_, err := b.FireAmendCashTransfer(ctx, bca.AmendCashTransferRequest{
+			Authentication: authentication,
+			BeneficiaryDetails: bca.CashTransferRequestBeneficiaryDetails{
+				Name:                 "Other Beneficiary",
+				IdentificationType:   "0001",
+				IdentificationNumber: "3171000000000002",
+			},
+			TransactionDetails: bca.AmendCashTransferRequestTransactionDetails{
+				ReferenceNumber: details.ReferenceNumber,
+				FormNumber:      details.FormNumber,
+				SecretQuestion:  "Favourite food",
+				SecretAnswer:    "Rendang",
+			},
+		})
+		return err
+	}
+	cancel := func(details bca.FireTransactionDetails) error {
+		_, err := b.FireCancelCashTransfer(ctx, bca.CancelCashTransferRequest{
+			Authentication: authentication,
+			TransactionDetails: bca.CancelCashTransferRequestTransactionDetails{
+				ReferenceNumber: details.ReferenceNumber,
+				FormNumber:      details.FormNumber,
+				Reason:          "Requested by sender",
+			},
+		})
+		return err
+	}
+
+	errorCode := func(t *testing.T, err error) string {
+		apiErr, ok := bca.AsAPIError(err)
+		require.True(t, ok, "%v", err)
+		return apiErr.ErrorCode
+	}
+
+	t.Run("amend then pick up", func(t *testing.T) {
+		details := transfer("20170105011")
+		require.NoError(t, amend(details))
+		require.False(t, srv.PayCashTransfer(details.ReferenceNumber, "Siti"))
+		require.True(t, srv.PayCashTransfer(details.ReferenceNumber, "Rendang"))
+
+		require.Equal(t, bca.ErrCodeInvalidParameter, errorCode(t, amend(details)))
+		require.Equal(t, bca.ErrCodeInvalidParameter, errorCode(t, cancel(details)))
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		details := transfer("20170105012")
+		require.NoError(t, cancel(details))
+		state, _ := srv.CashTransferState(details.ReferenceNumber)
+		require.Equal(t, bcamock.CashTransferCancelled, state)
+
+		require.False(t, srv.PayCashTransfer(details.ReferenceNumber, "Siti"))
+		require.Equal(t, bca.ErrCodeInvalidParameter, errorCode(t, cancel(details)))
+	})
+
+	t.Run("unknown cash transfer", func(t *testing.T) {
+		err := cancel(bca.FireTransactionDetails{ReferenceNumber: "9999999999999999", FormNumber: "20170105013"})
+		require.True(t, bca.IsTransactionNotFound(err))
+	})
+
+	t.Run("duplicate FormNumber is not retried", func(t *testing.T) {
+		_, err := b.FireCashTransfer(ctx, givenCashTransferRequest("20170105011"))
+		require.True(t, bca.IsDuplicateTransaction(err))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		dtoReq := givenCashTransferRequest("20170105014")
+		dtoReq.BeneficiaryDetails.IdentificationNumber = ""
+		dtoReq.TransactionDetails.SecretAnswer = ""
+		_, err := b.FireCashTransfer(ctx, dtoReq)
+		require.Equal(t, bca.ErrorClassValidation, bca.ClassOf(err))
+		require.Contains(t, err.Error(), "BeneficiaryDetails.IdentificationNumber")
+		require.Contains(t, err.Error(), "TransactionDetails.SecretAnswer")
+	})
+
+	t.Run("secret answer is not logged", func(t *testing.T) {
+		logged, err := ioutil.ReadFile(config.LogPath)
+		require.NoError(t, err)
+		require.Contains(t, string(logged), "Mother's maiden name")
+		require.Contains(t, string(logged), "Favourite food")
+		require.NotContains(t, string(logged), "Siti")
+		require.NotContains(t, string(logged), "Rendang")
+	})
+}
+
+func TestBCA_FireInquiryTransaction(t *testing.T) {
+	srv := bcamock.NewServer(bcamock.Config{})
+	defer srv.Close()
+	srv.AddAccount(bcamock.Account{AccountNumber: "0106666011", Name: "STEVEN"})
+	srv.AddAccount(bcamock.Account{AccountNumber: "0201245680", Balance: bca.NewAmount(1000000, 0)})
+
+	b := bca.New(srv.ClientConfig())
+	ctx := context.Background()
+	authentication := givenCashTransferRequest("").Authentication
+
+	inquire := func(inquiryBy, inquiryValue string) (*bca.InquiryTransactionResponse, error) {
+		return b.FireInquiryTransaction(ctx, bca.InquiryTransactionRequest{
+			Authentication: authentication,
+			TransactionDetails: bca.InquiryTransactionRequestTransactionDetails{
+				InquiryBy:    inquiryBy,
+				InquiryValue: inquiryValue,
+			},
+		})
+	}
+
+	transferResp, err := b.FireTransferToAccount(ctx, givenTransferToAccountRequest("20170105021"))
+	require.NoError(t, err)
+	require.Equal(t, bca.FireStatusSuccess, transferResp.Status())
+
+	dtoResp, err := inquire(bca.FireInquiryByFormNumber, "20170105021")
+	require.NoError(t, err)
+	require.Equal(t, bca.FireStatusSuccess, dtoResp.Status())
+	require.Equal(t, transferResp.TransactionDetails, dtoResp.TransactionDetails)
+	require.Equal(t, "Sender", dtoResp.SenderDetails.FirstName)
+	require.Equal(t, "0106666011", dtoResp.BeneficiaryDetails.AccountNumber)
+
+	t.Run("cash transfer", func(t *testing.T) {
+		cashResp, err := b.FireCashTransfer(ctx, givenCashTransferRequest("20170105022"))
+		require.NoError(t, err)
+		referenceNumber := cashResp.TransactionDetails.ReferenceNumber
+
+		dtoResp, err := inquire(bca.FireInquiryByReferenceNumber, referenceNumber)
+		require.NoError(t, err)
+		require.Equal(t, bca.FireStatusSuccess, dtoResp.Status())
+		require.Equal(t, "20170105022", dtoResp.TransactionDetails.FormNumber)
+	})
+
+	t.Run("unknown transaction", func(t *testing.T) {
+		_, err := inquire(bca.FireInquiryByReferenceNumber, "9999999999999999")
+		require.True(t, bca.IsTransactionNotFound(err))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := inquire("X", "")
+		require.Equal(t, bca.ErrorClassValidation, bca.ClassOf(err))
+		require.Contains(t, err.Error(), "TransactionDetails.InquiryBy")
+		require.Contains(t, err.Error(), "TransactionDetails.InquiryValue")
+	})
+
+	t.Run("balance", func(t *testing.T) {
+		dtoResp, err := b.FireInquiryBalance(ctx, bca.InquiryBalanceRequest{
+			Authentication: authentication,
+			FIDetails:      bca.InquiryBalanceRequestFIDetails{CurrencyID: "IDR", AccountNumber: "0201245680"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, bca.FireStatusSuccess, dtoResp.Status())
+		require.Equal(t, bca.InquiryBalanceResponseFIDetails{CurrencyID: "IDR", AccountBalance: bca.NewAmount(1000000, 0)}, dtoResp.FIDetails)
+
+		_, err = b.FireInquiryBalance(ctx, bca.InquiryBalanceRequest{
+			Authentication: authentication,
+			FIDetails:      bca.InquiryBalanceRequestFIDetails{CurrencyID: "USD", AccountNumber: "0201245680"},
+		})
+		apiErr, ok := bca.AsAPIError(err)
+		require.True(t, ok)
+		require.Equal(t, bca.ErrCodeInvalidAccount, apiErr.ErrorCode)
+	})
+}
+
+// authenticationRecorder records Authentication of FIRe requests sent through it
+type authenticationRecorder struct {
+	authentications []bca.Authentication
+}
+
+func (r *authenticationRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.Path, "/fire/") && req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		var dtoReq struct{ Authentication bca.Authentication }
+		if err := json.Unmarshal(body, &dtoReq); err != nil {
+			return nil, err
+		}
+		r.authentications = append(r.authentications, dtoReq.Authentication)
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestBCA_Fire_authenticationFromConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bca-log")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srv := bcamock.NewServer(bcamock.Config{})
+	defer srv.Close()
+	srv.AddAccount(bcamock.Account{AccountNumber: "0106666011", Name: "STEVEN"})
+
+	givenFire := bca.FireConfig{CorporateID: "DUMMYI", AccessCode: "Kw5oTuF12dseSH44Y8ww", BranchCode: "BCA001", UserID: "BCAUSERID", LocalID: "40115"}
+	recorder := &authenticationRecorder{}
+	config := srv.ClientConfig()
+	config.Fire = givenFire
+	config.HTTPClient = &http.Client{Transport: recorder}
+	config.LogPath = filepath.Join(dir, "bca.log")
+	b := bca.New(config)
+	ctx := context.Background()
+
+	_, err = b.FireInquiryAccount(ctx, bca.InquiryAccountRequest{
+		BeneficiaryDetails: bca.InquiryAccountRequestBeneficiaryDetails{BankCodeType: "BIC", BankCodeValue: "CENAIDJAXXX", AccountNumber: "0106666011"},
+	})
+	require.NoError(t, err)
+
+	dtoReq := givenTransferToAccountRequest("20170105031")
+	dtoReq.Authentication = bca.Authentication{}
+	_, err = b.FireTransferToAccount(ctx, dtoReq)
+	require.NoError(t, err)
+
+	// given Authentication is kept
+	dtoReq = givenTransferToAccountRequest("20170105032")
+	dtoReq.Authentication.UserID = "OTHERUSER"
+	_, err = b.FireTransferToAccount(ctx, dtoReq)
+	require.NoError(t, err)
+
+	expectedOther := givenTransferToAccountRequest("").Authentication
+	expectedOther.UserID = "OTHERUSER"
+	require.Equal(t, []bca.Authentication{
+		{CorporateID: "DUMMYI", AccessCode: "Kw5oTuF12dseSH44Y8ww", BranchCode: "BCA001", UserID: "BCAUSERID", LocalID: "40115"},
+		{CorporateID: "DUMMYI", AccessCode: "Kw5oTuF12dseSH44Y8ww", BranchCode: "BCA001", UserID: "BCAUSERID", LocalID: "40115"},
+		expectedOther,
+	}, recorder.authentications)
+
+	t.Run("access code is not logged", func(t *testing.T) {
+		logged, err := ioutil.ReadFile(config.LogPath)
+		require.NoError(t, err)
+		require.Contains(t, string(logged), "BCAUSERID")
+		require.NotContains(t, string(logged), givenFire.AccessCode)
+		require.NotContains(t, string(logged), expectedOther.AccessCode)
+	})
+
+	t.Run("without FIRe config", func(t *testing.T) {
+		b := bca.New(srv.ClientConfig())
+		_, err := b.FireInquiryAccount(ctx, bca.InquiryAccountRequest{
+			BeneficiaryDetails: bca.InquiryAccountRequestBeneficiaryDetails{BankCodeType: "BIC", BankCodeValue: "CENAIDJAXXX", AccountNumber: "0106666011"},
+		})
+		require.Equal(t, bca.ErrorClassValidation, bca.ClassOf(err))
+		require.Contains(t, err.Error(), "Authentication.AccessCode")
+	})
 }
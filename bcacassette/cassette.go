@@ -0,0 +1,330 @@
+// Package bcacassette records HTTP exchanges with BCA API into a cassette and replays them in tests.
+//
+// A cassette is JSON lines file, one Interaction per line, with secrets and access tokens redacted.
+// Replayed requests are matched on method, path (with sorted query) and canonicalized body,
+// ignoring headers such as X-BCA-Timestamp and X-BCA-Signature.
+//
+//	c, err := bcacassette.Replay("testdata/transfer.jsonl", bcacassette.Options{})
+//	config.HTTPClient = c.Client()
+package bcacassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// Redacted replaces redacted values in the cassette
+const Redacted = "REDACTED"
+
+// Represent default redacted headers and body fields
+var (
+	DefaultRedactHeaders = []string{"Authorization", "X-BCA-Key", "X-BCA-Signature", "Set-Cookie", "Cookie"}
+	DefaultRedactFields  = []string{"access_token", "client_id", "client_secret", "AccessCode", "SecretAnswer"}
+)
+
+// ErrInteractionNotFound is returned when replayed request has no matching interaction left in the cassette
+var ErrInteractionNotFound = errors.New("interaction not found in cassette")
+
+// Options controls Cassette, zero fields take the defaults
+type Options struct {
+	// Transport sends recorded requests, default is http.DefaultTransport
+	Transport http.RoundTripper
+	// RedactHeaders are request and response headers whose values are redacted, default is DefaultRedactHeaders
+	RedactHeaders []string
+	// RedactFields are fields of JSON or form body whose values are redacted, default is DefaultRedactFields
+	RedactFields []string
+}
+
+func (o Options) withDefaults() Options {
+	if o.Transport == nil {
+		o.Transport = http.DefaultTransport
+	}
+	if o.RedactHeaders == nil {
+		o.RedactHeaders = DefaultRedactHeaders
+	}
+	if o.RedactFields == nil {
+		o.RedactFields = DefaultRedactFields
+	}
+	return o
+}
+
+// Interaction is recorded exchange
+type Interaction struct {
+	Request  Request
+	Response Response
+}
+
+// Request is recorded request, Path includes sorted query
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header `json:",omitempty"`
+	Body   string      `json:",omitempty"`
+}
+
+// Response is recorded response
+type Response struct {
+	StatusCode int
+	Header     http.Header `json:",omitempty"`
+	Body       string      `json:",omitempty"`
+}
+
+// Cassette is http.RoundTripper which either records exchanges into a cassette file or replays them from it
+type Cassette struct {
+	opts      Options
+	recording bool
+
+	mutex        sync.Mutex
+	file         *os.File       // nil when replaying or closed
+	interactions []*Interaction // not yet replayed interactions
+}
+
+// Record return Cassette sending requests using Options.Transport and recording them into new file at path
+func Record(path string, opts Options) (*Cassette, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Cassette{opts: opts.withDefaults(), recording: true, file: f}, nil
+}
+
+// Replay return Cassette answering requests from interactions recorded in file at path, each is replayed once
+func Replay(path string, opts Options) (*Cassette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+
+	c := &Cassette{opts: opts.withDefaults()}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, errors.Annotatef(err, "cassette %s", path)
+		}
+		c.interactions = append(c.interactions, &interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return c, nil
+}
+
+// Client return http.Client using c as its Transport, e.g. for bca.Config.HTTPClient
+func (c *Cassette) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// Close close the cassette file being recorded
+func (c *Cassette) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return errors.Trace(err)
+}
+
+// RoundTrip record or replay req
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, errors.Trace(err)
+		}
+		req.Body.Close()
+	}
+
+	if c.recording {
+		return c.record(req, body)
+	}
+	return c.replay(req, body)
+}
+
+func (c *Cassette) record(req *http.Request, body []byte) (*http.Response, error) {
+	sent := req.Clone(req.Context())
+	sent.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := c.opts.Transport.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			Path:   requestPath(req.URL),
+			Header: c.redactHeader(req.Header),
+			Body:   c.redactBody(req.Header, body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     c.redactHeader(resp.Header),
+			Body:       c.redactBody(resp.Header, respBody),
+		},
+	}
+	// redacted body may differ in length
+	interaction.Response.Header.Del("Content-Length")
+
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.file == nil {
+		return nil, errors.New("cassette is closed")
+	}
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	path := requestPath(req.URL)
+	canonicalBody := canonicalize(c.redactBody(req.Header, body))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, interaction := range c.interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.Path != path ||
+			canonicalize(interaction.Request.Body) != canonicalBody {
+			continue
+		}
+		c.interactions = append(c.interactions[:i:i], c.interactions[i+1:]...)
+
+		header := interaction.Response.Header
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        http.StatusText(interaction.Response.StatusCode),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, errors.Annotatef(ErrInteractionNotFound, "%s %s", req.Method, path)
+}
+
+// requestPath return path of u with sorted query
+func requestPath(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.EscapedPath()
+	}
+	return u.EscapedPath() + "?" + u.Query().Encode()
+}
+
+func (c *Cassette) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range c.opts.RedactHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, Redacted)
+		}
+	}
+	return redacted
+}
+
+// redactBody redact fields of JSON or form body, other bodies are kept as is
+func (c *Cassette) redactBody(header http.Header, body []byte) string {
+	if strings.HasPrefix(header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		for _, field := range c.opts.RedactFields {
+			if _, ok := form[field]; ok {
+				form.Set(field, Redacted)
+			}
+		}
+		return form.Encode()
+	}
+
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return string(body)
+	}
+	redacted, err := json.Marshal(c.redactJSON(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func (c *Cassette) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if c.redactedField(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = c.redactJSON(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = c.redactJSON(value)
+		}
+	}
+	return v
+}
+
+func (c *Cassette) redactedField(key string) bool {
+	for _, field := range c.opts.RedactFields {
+		if strings.EqualFold(field, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalize return JSON body with sorted keys and no whitespace, other bodies are kept as is
+func canonicalize(body string) string {
+	var v interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return body
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return string(canonical)
+}
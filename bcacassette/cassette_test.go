@@ -0,0 +1,113 @@
+package bcacassette_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/purwaren/bca-api"
+	"github.com/purwaren/bca-api/bcacassette"
+	"github.com/purwaren/bca-api/bcamock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCassette(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bca-cassette")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.jsonl")
+
+	srv := bcamock.NewServer(bcamock.Config{})
+	srv.AddAccount(bcamock.Account{AccountNumber: "0201245680", Balance: bca.NewAmount(1000000, 0)})
+	srv.AddAccount(bcamock.Account{AccountNumber: "0201245681"})
+	srv.AddAccount(bcamock.Account{AccountNumber: "0106666011", Name: "STEVEN"})
+	config := srv.ClientConfig()
+
+	transferReq := bca.FundTransferRequest{
+		SourceAccountNumber:      "0201245680",
+		TransactionID:            "00000001",
+		TransactionDate:          "2016-01-30",
+		ReferenceID:              "12345/PO/2016",
+		CurrencyCode:             "IDR",
+		Amount:                   bca.NewAmount(100000, 0),
+		BeneficiaryAccountNumber: "0201245681",
+	}
+	inquiryReq := bca.InquiryAccountRequest{
+		Authentication: bca.Authentication{CorporateID: "DUMMYI", AccessCode: "Kw5oTuF12dseSH44Y8ww", BranchCode: "BCA001", UserID: "BCAUSERID", LocalID: "40115"},
+		BeneficiaryDetails: bca.InquiryAccountRequestBeneficiaryDetails{
+			BankCodeType:  "BIC",
+			BankCodeValue: "CENAIDJAXXX",
+			AccountNumber: "0106666011",
+		},
+	}
+	cashTransferReq := bca.CashTransferRequest{
+		Authentication: inquiryReq.Authentication,
+		SenderDetails: bca.SenderDetails{
+			FirstName:            "Sender",
+			Address1:             "Sender Address",
+			City:                 "Hong Kong",
+			CountryID:            "HK",
+			IdentificationType:   "0001",
+			IdentificationNumber: "A123456",
+		},
+		BeneficiaryDetails: bca.CashTransferRequestBeneficiaryDetails{
+			Name:                 "Beneficiary Name",
+			IdentificationType:   "0001",
+			IdentificationNumber: "3171000000000002",
+		},
+		TransactionDetails: bca.CashTransferRequestTransactionDetails{
+			CurrencyID:     "IDR",
+			Amount:         bca.NewAmount(100000, 0),
+			PurposeCode:    "011",
+			FormNumber:     "20170105011",
+			SecretQuestion: "Mother's maiden name",
+			SecretAnswer:   "Siti",
+		},
+	}
+	exchange := func(b *bca.BCA) (*bca.FundTransferResponse, *bca.AccountStatementResponse, *bca.InquiryAccountResponse) {
+		ctx := context.Background()
+		transferResp, err := b.BankingFundTransfer(ctx, transferReq)
+		require.NoError(t, err)
+		statementResp, err := b.BankingGetStatement(ctx, bca.AccountStatementRequest{AccountNumber: "0201245680", StartDate: "2016-01-30", EndDate: "2016-01-30"})
+		require.NoError(t, err)
+		inquiryResp, err := b.FireInquiryAccount(ctx, inquiryReq)
+		require.NoError(t, err)
+		_, err = b.FireCashTransfer(ctx, cashTransferReq)
+		require.NoError(t, err)
+		return transferResp, statementResp, inquiryResp
+	}
+
+	// record
+	c, err := bcacassette.Record(path, bcacassette.Options{})
+	require.NoError(t, err)
+	config.HTTPClient = c.Client()
+	recordedTransfer, recordedStatement, recordedInquiry := exchange(bca.New(config))
+	require.NoError(t, c.Close())
+	srv.Close()
+
+	recorded, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, strings.Split(strings.TrimSpace(string(recorded)), "\n"), 5)
+	for _, secret := range []string{bcamock.DefaultClientSecret, bcamock.DefaultAPIKey, "Kw5oTuF12dseSH44Y8ww", "Siti", "Bearer "} {
+		require.NotContains(t, string(recorded), secret)
+	}
+
+	// replay without the server
+	c, err = bcacassette.Replay(path, bcacassette.Options{})
+	require.NoError(t, err)
+	config.HTTPClient = c.Client()
+	b := bca.New(config)
+	replayedTransfer, replayedStatement, replayedInquiry := exchange(b)
+	require.Equal(t, recordedTransfer, replayedTransfer)
+	require.Equal(t, recordedStatement, replayedStatement)
+	require.Equal(t, recordedInquiry, replayedInquiry)
+
+	t.Run("each interaction is replayed once", func(t *testing.T) {
+		_, err := b.FireInquiryAccount(context.Background(), inquiryReq)
+		require.Equal(t, bca.ErrorClassTransport, bca.ClassOf(err))
+		require.Contains(t, err.Error(), bcacassette.ErrInteractionNotFound.Error())
+	})
+}
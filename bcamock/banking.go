@@ -0,0 +1,242 @@
+package bcamock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/purwaren/bca-api"
+)
+
+// StatusSuccess is Status of transfers booked by the mock
+const StatusSuccess = "Success"
+
+func (s *Server) serveBalance(w http.ResponseWriter, r *http.Request, params []string) {
+	if !s.checkCorporateID(w, params[0]) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var dtoResp bca.BalanceInfoResponse
+	for _, accountNumber := range strings.Split(params[1], ",") {
+		account, ok := s.accounts[accountNumber]
+		if !ok {
+			dtoResp.AccountDetailDataFailed = append(dtoResp.AccountDetailDataFailed, bca.AccountBalance{
+				AccountNumber: accountNumber,
+				Indonesian:    "Nomor rekening tidak valid",
+				English:       "Invalid account number",
+			})
+			continue
+		}
+		dtoResp.AccountDetailDataSuccess = append(dtoResp.AccountDetailDataSuccess, bca.AccountBalance{
+			AccountNumber:    account.AccountNumber,
+			Currency:         account.Currency,
+			Balance:          account.Balance,
+			AvailableBalance: account.Balance,
+		})
+	}
+	writeJSON(w, http.StatusOK, dtoResp)
+}
+
+func (s *Server) serveStatement(w http.ResponseWriter, r *http.Request, params []string) {
+	dtoReq := bca.AccountStatementRequest{
+		AccountNumber: params[1],
+		StartDate:     r.URL.Query().Get("StartDate"),
+		EndDate:       r.URL.Query().Get("EndDate"),
+	}
+	if !s.checkCorporateID(w, params[0]) || !checkRequest(w, dtoReq) {
+		return
+	}
+	if dtoReq.EndDate < dtoReq.StartDate {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidParameter, "Parameter tidak valid", "Invalid parameter")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	account, ok := s.accounts[dtoReq.AccountNumber]
+	if !ok {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidAccount, "Rekening tidak valid", "Invalid account")
+		return
+	}
+
+	startBalance, statements := account.statement(dtoReq.StartDate, dtoReq.EndDate)
+	writeJSON(w, http.StatusOK, bca.AccountStatementResponse{
+		StartDate:    dtoReq.StartDate,
+		EndDate:      dtoReq.EndDate,
+		Currency:     account.Currency,
+		StartBalance: startBalance,
+		Data:         statements,
+	})
+}
+
+func (s *Server) serveTransfer(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.FundTransferRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) || !s.checkCorporateID(w, dtoReq.CorporateID) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := transferKey{dtoReq.TransactionDate, dtoReq.TransactionID}
+	source, ok := s.debitable(w, key, dtoReq.SourceAccountNumber, dtoReq.Amount)
+	if !ok {
+		return
+	}
+	beneficiary, ok := s.accounts[dtoReq.BeneficiaryAccountNumber]
+	if !ok {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidAccount, "Rekening tidak valid", "Invalid account")
+		return
+	}
+
+	source.book(dtoReq.TransactionDate, TransactionDebit, dtoReq.Amount, "TRSF E-BANKING DB", dtoReq.ReferenceID)
+	beneficiary.book(dtoReq.TransactionDate, TransactionCredit, dtoReq.Amount, "TRSF E-BANKING CR", dtoReq.ReferenceID)
+
+	s.transfers[key] = bca.TransferStatusResponse{
+		TransactionID:            dtoReq.TransactionID,
+		TransactionDate:          dtoReq.TransactionDate,
+		TransferType:             bca.TransferTypeBCA,
+		ReferenceID:              dtoReq.ReferenceID,
+		SourceAccountNumber:      dtoReq.SourceAccountNumber,
+		BeneficiaryAccountNumber: dtoReq.BeneficiaryAccountNumber,
+		Amount:                   dtoReq.Amount,
+		CurrencyCode:             dtoReq.CurrencyCode,
+		Status:                   StatusSuccess,
+	}
+	writeJSON(w, http.StatusOK, bca.FundTransferResponse{
+		TransactionID:   dtoReq.TransactionID,
+		TransactionDate: dtoReq.TransactionDate,
+		ReferenceID:     dtoReq.ReferenceID,
+		Status:          StatusSuccess,
+	})
+}
+
+func (s *Server) serveTransferDomestic(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.FundTransferDomesticRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := transferKey{dtoReq.TransactionDate, dtoReq.TransactionID}
+	source, ok := s.debitable(w, key, dtoReq.SourceAccountNumber, dtoReq.Amount)
+	if !ok {
+		return
+	}
+
+	// beneficiary is account of another bank, only the source account is booked
+	source.book(dtoReq.TransactionDate, TransactionDebit, dtoReq.Amount, "TRSF "+dtoReq.TransferType+" DB", dtoReq.ReferenceID)
+
+	s.sequence++
+	ppuNumber := fmt.Sprintf("%010d", s.sequence)
+	s.transfers[key] = bca.TransferStatusResponse{
+		TransactionID:            dtoReq.TransactionID,
+		TransactionDate:          dtoReq.TransactionDate,
+		TransferType:             dtoReq.TransferType,
+		ReferenceID:              dtoReq.ReferenceID,
+		SourceAccountNumber:      dtoReq.SourceAccountNumber,
+		BeneficiaryAccountNumber: dtoReq.BeneficiaryAccountNumber,
+		BeneficiaryBankCode:      dtoReq.BeneficiaryBankCode,
+		BeneficiaryName:          dtoReq.BeneficiaryName,
+		Amount:                   dtoReq.Amount,
+		CurrencyCode:             dtoReq.CurrencyCode,
+		PPUNumber:                ppuNumber,
+		Status:                   StatusSuccess,
+	}
+	writeJSON(w, http.StatusOK, bca.FundTransferDomesticResponse{
+		TransactionID:   dtoReq.TransactionID,
+		TransactionDate: dtoReq.TransactionDate,
+		ReferenceID:     dtoReq.ReferenceID,
+		PPUNumber:       ppuNumber,
+		Status:          StatusSuccess,
+	})
+}
+
+func (s *Server) serveTransferStatus(w http.ResponseWriter, r *http.Request, params []string) {
+	dtoReq := bca.TransferStatusRequest{
+		TransactionID:   params[0],
+		TransactionDate: r.URL.Query().Get("TransactionDate"),
+		TransferType:    r.URL.Query().Get("TransferType"),
+	}
+	if !checkRequest(w, dtoReq) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dtoResp, ok := s.transfers[transferKey{dtoReq.TransactionDate, dtoReq.TransactionID}]
+	if !ok || dtoResp.TransferType != dtoReq.TransferType {
+		writeError(w, http.StatusNotFound, bca.ErrCodeTransactionNotFound, "Transaksi tidak ditemukan", "Transaction not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, dtoResp)
+}
+
+func (s *Server) serveDomesticAccount(w http.ResponseWriter, r *http.Request, params []string) {
+	dtoReq := bca.DomesticAccountRequest{
+		BeneficiaryBankCode:      params[0],
+		BeneficiaryAccountNumber: params[1],
+	}
+	if !checkRequest(w, dtoReq) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	name, ok := s.domesticAccounts[domesticAccountKey{dtoReq.BeneficiaryBankCode, dtoReq.BeneficiaryAccountNumber}]
+	if !ok {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidAccount, "Rekening tidak valid", "Invalid account")
+		return
+	}
+	writeJSON(w, http.StatusOK, bca.DomesticAccountResponse{
+		BeneficiaryBankCode:      dtoReq.BeneficiaryBankCode,
+		BeneficiaryAccountNumber: dtoReq.BeneficiaryAccountNumber,
+		BeneficiaryName:          name,
+	})
+}
+
+// debitable return source account of transfer if it may be debited by amount.
+// Otherwise respond ESB-82-019 for TransactionID used on the same day, ESB-82-005 for unknown account
+// or ESB-82-006 for insufficient funds. s.mutex must be held.
+func (s *Server) debitable(w http.ResponseWriter, key transferKey, accountNumber string, amount bca.Amount) (*account, bool) {
+	if _, ok := s.transfers[key]; ok {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeDuplicateTransaction, "TransactionID sudah digunakan", "Duplicate TransactionID")
+		return nil, false
+	}
+	source, ok := s.accounts[accountNumber]
+	if !ok {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidAccount, "Rekening tidak valid", "Invalid account")
+		return nil, false
+	}
+	if source.Balance.Cmp(amount) < 0 {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInsufficientFunds, "Saldo tidak cukup", "Insufficient funds")
+		return nil, false
+	}
+	return source, true
+}
+
+func (s *Server) checkCorporateID(w http.ResponseWriter, corporateID string) bool {
+	if corporateID != s.config.CorporateID {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidCorporateID, "CorporateID tidak valid", "Invalid CorporateID")
+		return false
+	}
+	return true
+}
+
+// checkRequest validate dtoReq as BCA does, respond ESB-82-002 if it is invalid
+func checkRequest(w http.ResponseWriter, dtoReq validation.Validatable) bool {
+	if err := dtoReq.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidFieldFormat, "Format field tidak valid", "Invalid field format")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,315 @@
+package bcamock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/purwaren/bca-api"
+)
+
+// bcaBIC is BIC of BCA, beneficiary of FIRe transfer having this BankCodeValue is account of the mock
+const bcaBIC = "CENAIDJA"
+
+// Represent StatusTransaction and StatusMessage of successful FIRe request
+const (
+	FireStatusSuccess  = "0000"
+	FireMessageSuccess = "Success"
+)
+
+// fireTransfer is FIRe transfer to account or cash transfer
+type fireTransfer struct {
+	details     bca.FireTransactionDetails
+	sender      bca.InquiryTransactionResponseSenderDetails
+	beneficiary bca.InquiryTransactionResponseBeneficiaryDetails
+	cash        *cashTransfer // nil for transfer to account
+}
+
+func (s *Server) serveFireInquiryAccount(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.InquiryAccountRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	account, ok := s.accounts[dtoReq.BeneficiaryDetails.AccountNumber]
+	if !ok {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidAccount, "Rekening tidak valid", "Invalid account")
+		return
+	}
+	writeJSON(w, http.StatusOK, bca.InquiryAccountResponse{
+		BeneficiaryDetails: bca.InquiryAccountResponseBeneficiaryDetails{ServerBeneAccountName: account.Name},
+		StatusTransaction:  FireStatusSuccess,
+		StatusMessage:      FireMessageSuccess,
+	})
+}
+
+func (s *Server) serveFireTransferToAccount(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.TransferToAccountRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) {
+		return
+	}
+	beneficiary, transaction := dtoReq.BeneficiaryDetails, dtoReq.TransactionDetails
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.fireTransfers[transaction.FormNumber]; ok {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeDuplicateTransaction, "FormNumber sudah digunakan", "Duplicate FormNumber")
+		return
+	}
+
+	beneAccountName := beneficiary.Name
+	if strings.HasPrefix(beneficiary.BankCodeValue, bcaBIC) {
+		account, ok := s.accounts[beneficiary.AccountNumber]
+		if !ok {
+			writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidAccount, "Rekening tidak valid", "Invalid account")
+			return
+		}
+		account.book(time.Now().Format("2006-01-02"), TransactionCredit, transaction.Amount, "KR FIRE", transaction.FormNumber)
+		beneAccountName = account.Name
+	}
+
+	s.sequence++
+	details := bca.FireTransactionDetails{
+		CurrencyID:      transaction.CurrencyID,
+		Amount:          transaction.Amount,
+		Description1:    transaction.Description1,
+		Description2:    transaction.Description2,
+		FormNumber:      transaction.FormNumber,
+		ReferenceNumber: fmt.Sprintf("%016d", s.sequence),
+		ReleaseDateTime: time.Now().Format("2006-01-02T15:04:05"),
+	}
+	s.fireTransfers[transaction.FormNumber] = &fireTransfer{
+		details:     details,
+		sender:      senderOf(dtoReq.SenderDetails),
+		beneficiary: bca.InquiryTransactionResponseBeneficiaryDetails{Name: beneficiary.Name, AccountNumber: beneficiary.AccountNumber},
+	}
+
+	writeJSON(w, http.StatusOK, bca.TransferToAccountResponse{
+		BeneficiaryDetails: bca.TransferToAccountResponseBeneficiaryDetails{
+			Name:                  beneficiary.Name,
+			AccountNumber:         beneficiary.AccountNumber,
+			ServerBeneAccountName: beneAccountName,
+		},
+		TransactionDetails: details,
+		StatusTransaction:  FireStatusSuccess,
+		StatusMessage:      FireMessageSuccess,
+	})
+}
+
+// Represent state of FIRe cash transfer
+const (
+	CashTransferUnpaid    = "Unpaid"
+	CashTransferPaid      = "Paid"
+	CashTransferCancelled = "Cancelled"
+)
+
+type cashTransfer struct {
+	details        bca.FireTransactionDetails
+	beneficiary    bca.CashTransferRequestBeneficiaryDetails
+	secretQuestion string
+	secretAnswer   string
+	state          string
+}
+
+// CashTransferState return state of FIRe cash transfer of referenceNumber
+func (s *Server) CashTransferState(referenceNumber string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transfer, ok := s.cashTransfers[referenceNumber]
+	if !ok {
+		return "", false
+	}
+	return transfer.state, true
+}
+
+// PayCashTransfer simulate the beneficiary picking up unpaid cash transfer of referenceNumber at BCA branch,
+// secretAnswer must answer its secret question
+func (s *Server) PayCashTransfer(referenceNumber, secretAnswer string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transfer, ok := s.cashTransfers[referenceNumber]
+	if !ok || transfer.state != CashTransferUnpaid || transfer.secretAnswer != secretAnswer {
+		return false
+	}
+	transfer.state = CashTransferPaid
+	return true
+}
+
+func (s *Server) serveFireCashTransfer(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.CashTransferRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) {
+		return
+	}
+	transaction := dtoReq.TransactionDetails
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.fireTransfers[transaction.FormNumber]; ok {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeDuplicateTransaction, "FormNumber sudah digunakan", "Duplicate FormNumber")
+		return
+	}
+
+	s.sequence++
+	details := bca.FireTransactionDetails{
+		CurrencyID:      transaction.CurrencyID,
+		Amount:          transaction.Amount,
+		Description1:    transaction.Description1,
+		Description2:    transaction.Description2,
+		FormNumber:      transaction.FormNumber,
+		ReferenceNumber: fmt.Sprintf("%016d", s.sequence),
+		ReleaseDateTime: time.Now().Format("2006-01-02T15:04:05"),
+	}
+	cash := &cashTransfer{
+		details:        details,
+		beneficiary:    dtoReq.BeneficiaryDetails,
+		secretQuestion: transaction.SecretQuestion,
+		secretAnswer:   transaction.SecretAnswer,
+		state:          CashTransferUnpaid,
+	}
+	s.fireTransfers[transaction.FormNumber] = &fireTransfer{
+		details:     details,
+		sender:      senderOf(dtoReq.SenderDetails),
+		beneficiary: bca.InquiryTransactionResponseBeneficiaryDetails{Name: dtoReq.BeneficiaryDetails.Name},
+		cash:        cash,
+	}
+	s.cashTransfers[details.ReferenceNumber] = cash
+
+	writeJSON(w, http.StatusOK, bca.CashTransferResponse{
+		BeneficiaryDetails: bca.CashTransferResponseBeneficiaryDetails{Name: dtoReq.BeneficiaryDetails.Name},
+		TransactionDetails: details,
+		StatusTransaction:  FireStatusSuccess,
+		StatusMessage:      FireMessageSuccess,
+	})
+}
+
+func (s *Server) serveFireAmendCashTransfer(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.AmendCashTransferRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) {
+		return
+	}
+	transaction := dtoReq.TransactionDetails
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transfer, ok := s.unpaidCashTransfer(w, transaction.ReferenceNumber, transaction.FormNumber)
+	if !ok {
+		return
+	}
+	transfer.beneficiary = dtoReq.BeneficiaryDetails
+	s.fireTransfers[transaction.FormNumber].beneficiary.Name = dtoReq.BeneficiaryDetails.Name
+	transfer.secretQuestion = transaction.SecretQuestion
+	transfer.secretAnswer = transaction.SecretAnswer
+
+	writeJSON(w, http.StatusOK, bca.AmendCashTransferResponse{
+		TransactionDetails: transfer.details,
+		StatusTransaction:  FireStatusSuccess,
+		StatusMessage:      FireMessageSuccess,
+	})
+}
+
+func (s *Server) serveFireCancelCashTransfer(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.CancelCashTransferRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) {
+		return
+	}
+	transaction := dtoReq.TransactionDetails
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transfer, ok := s.unpaidCashTransfer(w, transaction.ReferenceNumber, transaction.FormNumber)
+	if !ok {
+		return
+	}
+	transfer.state = CashTransferCancelled
+
+	writeJSON(w, http.StatusOK, bca.CancelCashTransferResponse{
+		TransactionDetails: transfer.details,
+		StatusTransaction:  FireStatusSuccess,
+		StatusMessage:      FireMessageSuccess,
+	})
+}
+
+// unpaidCashTransfer return unpaid cash transfer of referenceNumber and formNumber,
+// respond ESB-82-023 if it is not found or ESB-14-007 if it is paid or cancelled
+func (s *Server) unpaidCashTransfer(w http.ResponseWriter, referenceNumber, formNumber string) (*cashTransfer, bool) {
+	transfer, ok := s.cashTransfers[referenceNumber]
+	if !ok || transfer.details.FormNumber != formNumber {
+		writeError(w, http.StatusNotFound, bca.ErrCodeTransactionNotFound, "Transaksi tidak ditemukan", "Transaction not found")
+		return nil, false
+	}
+	if transfer.state != CashTransferUnpaid {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidParameter, "Transaksi sudah "+transfer.state, "Transaction is "+transfer.state)
+		return nil, false
+	}
+	return transfer, true
+}
+
+func (s *Server) serveFireInquiryTransaction(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.InquiryTransactionRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) {
+		return
+	}
+	inquiry := dtoReq.TransactionDetails
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var transfer *fireTransfer
+	if inquiry.InquiryBy == bca.FireInquiryByFormNumber {
+		transfer = s.fireTransfers[inquiry.InquiryValue]
+	} else {
+		for _, t := range s.fireTransfers {
+			if t.details.ReferenceNumber == inquiry.InquiryValue {
+				transfer = t
+				break
+			}
+		}
+	}
+	if transfer == nil {
+		writeError(w, http.StatusNotFound, bca.ErrCodeTransactionNotFound, "Transaksi tidak ditemukan", "Transaction not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bca.InquiryTransactionResponse{
+		SenderDetails:      transfer.sender,
+		BeneficiaryDetails: transfer.beneficiary,
+		TransactionDetails: transfer.details,
+		StatusTransaction:  FireStatusSuccess,
+		StatusMessage:      FireMessageSuccess,
+	})
+}
+
+func (s *Server) serveFireInquiryBalance(w http.ResponseWriter, r *http.Request, params []string) {
+	var dtoReq bca.InquiryBalanceRequest
+	if !decodeRequest(w, r, &dtoReq) || !checkRequest(w, dtoReq) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	account, ok := s.accounts[dtoReq.FIDetails.AccountNumber]
+	if !ok || account.Currency != dtoReq.FIDetails.CurrencyID {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidAccount, "Rekening tidak valid", "Invalid account")
+		return
+	}
+	writeJSON(w, http.StatusOK, bca.InquiryBalanceResponse{
+		FIDetails:         bca.InquiryBalanceResponseFIDetails{CurrencyID: account.Currency, AccountBalance: account.Balance},
+		StatusTransaction: FireStatusSuccess,
+		StatusMessage:     FireMessageSuccess,
+	})
+}
+
+func senderOf(sender bca.SenderDetails) bca.InquiryTransactionResponseSenderDetails {
+	return bca.InquiryTransactionResponseSenderDetails{FirstName: sender.FirstName, LastName: sender.LastName}
+}
@@ -0,0 +1,99 @@
+package bcamock
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/purwaren/bca-api"
+)
+
+// Represent TransactionType of account statement
+const (
+	TransactionDebit  = "D"
+	TransactionCredit = "C"
+)
+
+// account is Account with its booked transactions
+type account struct {
+	Account
+	entries []entry
+}
+
+// entry is transaction booked into account
+type entry struct {
+	date string // yyyy-MM-dd
+	bca.AccountStatement
+}
+
+// LoadAccounts add accounts read from JSON array of Account, e.g.
+//
+//	[{"AccountNumber":"0201245680","Name":"BCA API","Currency":"IDR","Balance":"1000000.00"}]
+func (s *Server) LoadAccounts(r io.Reader) error {
+	var accounts []Account
+	if err := json.NewDecoder(r).Decode(&accounts); err != nil {
+		return errors.Trace(err)
+	}
+	for _, account := range accounts {
+		if account.AccountNumber == "" {
+			return errors.NotValidf("account without AccountNumber")
+		}
+		s.AddAccount(account)
+	}
+	return nil
+}
+
+// LoadAccountsFile add accounts read from JSON fixture file, see LoadAccounts
+func (s *Server) LoadAccountsFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+
+	return errors.Trace(s.LoadAccounts(f))
+}
+
+// book add transaction of amount on date into a, debit decreases the balance
+func (a *account) book(date, transactionType string, amount bca.Amount, name, trailer string) {
+	if transactionType == TransactionDebit {
+		a.Balance = a.Balance.Sub(amount)
+	} else {
+		a.Balance = a.Balance.Add(amount)
+	}
+
+	statementDate := date
+	if t, err := time.Parse("2006-01-02", date); err == nil {
+		statementDate = t.Format("02/01")
+	}
+	a.entries = append(a.entries, entry{date: date, AccountStatement: bca.AccountStatement{
+		TransactionDate:   statementDate,
+		BranchCode:        "0000",
+		TransactionType:   transactionType,
+		TransactionAmount: amount,
+		TransactionName:   name,
+		Trailer:           trailer,
+	}})
+}
+
+// statement return balance of a at the beginning of startDate and its transactions from startDate to endDate
+func (a *account) statement(startDate, endDate string) (bca.Amount, []bca.AccountStatement) {
+	startBalance := a.Balance
+	statements := []bca.AccountStatement{}
+	for _, e := range a.entries {
+		if e.date < startDate {
+			continue
+		}
+		if e.TransactionType == TransactionDebit {
+			startBalance = startBalance.Add(e.TransactionAmount)
+		} else {
+			startBalance = startBalance.Sub(e.TransactionAmount)
+		}
+		if e.date <= endDate {
+			statements = append(statements, e.AccountStatement)
+		}
+	}
+	return startBalance, statements
+}
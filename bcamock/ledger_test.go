@@ -0,0 +1,102 @@
+package bcamock_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/purwaren/bca-api"
+	"github.com/purwaren/bca-api/bcamock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ledger(t *testing.T) {
+	srv := bcamock.NewServer(bcamock.Config{})
+	defer srv.Close()
+	require.NoError(t, srv.LoadAccountsFile("testdata/accounts.json"))
+
+	b := bca.New(srv.ClientConfig())
+	ctx := context.Background()
+
+	transfer := func(transactionID, transactionDate string, amount bca.Amount) error {
+		_, err := b.BankingFundTransfer(ctx, bca.FundTransferRequest{
+			SourceAccountNumber:      "0201245680",
+			TransactionID:            transactionID,
+			TransactionDate:          transactionDate,
+			ReferenceID:              "12345/PO/2016",
+			CurrencyCode:             "IDR",
+			Amount:                   amount,
+			BeneficiaryAccountNumber: "0201245681",
+		})
+		return err
+	}
+	balances := func() map[string]bca.Amount {
+		dtoResp, err := b.BankingGetBalance(ctx, bca.BalanceInfoRequest{AccountNumbers: []string{"0201245680", "0201245681"}})
+		require.NoError(t, err)
+		balances := map[string]bca.Amount{}
+		for _, balance := range dtoResp.AccountDetailDataSuccess {
+			balances[balance.AccountNumber] = balance.Balance
+		}
+		return balances
+	}
+
+	require.NoError(t, transfer("00000001", "2016-01-30", bca.NewAmount(300000, 0)))
+	require.NoError(t, transfer("00000002", "2016-01-30", bca.NewAmount(200000, 50)))
+	require.Equal(t, map[string]bca.Amount{"0201245680": bca.NewAmount(499999, 50), "0201245681": bca.NewAmount(500000, 50)}, balances())
+
+	t.Run("duplicate TransactionID on the same day", func(t *testing.T) {
+		require.True(t, bca.IsDuplicateTransaction(transfer("00000001", "2016-01-30", bca.NewAmount(1, 0))))
+		require.NoError(t, transfer("00000001", "2016-01-31", bca.NewAmount(1, 0)))
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		require.True(t, bca.IsInsufficientFunds(transfer("00000003", "2016-01-31", bca.NewAmount(500000, 0))))
+		require.Equal(t, map[string]bca.Amount{"0201245680": bca.NewAmount(499998, 50), "0201245681": bca.NewAmount(500001, 50)}, balances())
+	})
+
+	t.Run("domestic transfer", func(t *testing.T) {
+		_, err := b.BankingFundTransferDomestic(ctx, bca.FundTransferDomesticRequest{
+			TransactionID:            "00000004",
+			TransactionDate:          "2016-02-01",
+			ReferenceID:              "12345/PO/2016",
+			SourceAccountNumber:      "0201245680",
+			BeneficiaryAccountNumber: "0201245501",
+			BeneficiaryBankCode:      "BRONINJA",
+			BeneficiaryName:          "Tester",
+			Amount:                   bca.NewAmount(99998, 50),
+			TransferType:             bca.TransferTypeLLG,
+			BeneficiaryCustType:      "1",
+			BeneficiaryCustResidence: "1",
+			CurrencyCode:             "IDR",
+		})
+		require.NoError(t, err)
+		account, ok := srv.Account("0201245680")
+		require.True(t, ok)
+		require.Equal(t, bca.NewAmount(400000, 0), account.Balance)
+	})
+
+	t.Run("statement", func(t *testing.T) {
+		dtoResp, err := b.BankingGetStatement(ctx, bca.AccountStatementRequest{AccountNumber: "0201245680", StartDate: "2016-01-31", EndDate: "2016-01-31"})
+		require.NoError(t, err)
+		require.Equal(t, bca.NewAmount(499999, 50), dtoResp.StartBalance)
+		require.Equal(t, []bca.AccountStatement{{
+			TransactionDate:   "31/01",
+			BranchCode:        "0000",
+			TransactionType:   bcamock.TransactionDebit,
+			TransactionAmount: bca.NewAmount(1, 0),
+			TransactionName:   "TRSF E-BANKING DB",
+			Trailer:           "12345/PO/2016",
+		}}, dtoResp.Data)
+
+		dtoResp, err = b.BankingGetStatement(ctx, bca.AccountStatementRequest{AccountNumber: "0201245681", StartDate: "2016-01-01", EndDate: "2016-02-29"})
+		require.NoError(t, err)
+		require.Equal(t, bca.Amount(0), dtoResp.StartBalance)
+		require.Len(t, dtoResp.Data, 3)
+		require.Equal(t, bcamock.TransactionCredit, dtoResp.Data[0].TransactionType)
+	})
+
+	t.Run("invalid fixture", func(t *testing.T) {
+		require.Error(t, srv.LoadAccounts(strings.NewReader(`[{"Name":"no account number"}]`)))
+		require.Error(t, srv.LoadAccounts(strings.NewReader(`{`)))
+	})
+}
@@ -0,0 +1,392 @@
+// Package bcamock provides fake BCA API server for testing without network.
+//
+// The server issues OAuth access tokens, verifies X-BCA-Key, X-BCA-Timestamp and X-BCA-Signature the way BCA does,
+// and serves banking and FIRe endpoints from accounts added to it. Transfers move money between the accounts,
+// which is reflected in their balances and statements. Faults (BCA error responses, gateway pages, delays)
+// can be injected into any endpoint.
+package bcamock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/purwaren/bca-api"
+)
+
+// Represent endpoints served by the mock, path parameters are enclosed in braces
+const (
+	EndpointToken                  = "POST /api/oauth/token"
+	EndpointBalance                = "GET /banking/v3/corporates/{CorporateID}/accounts/{AccountNumbers}"
+	EndpointStatement              = "GET /banking/v3/corporates/{CorporateID}/accounts/{AccountNumber}/statements"
+	EndpointTransfer               = "POST /banking/corporates/transfers"
+	EndpointTransferDomestic       = "POST /banking/corporates/transfers/domestic"
+	EndpointTransferStatus         = "GET /banking/corporates/transfers/v2/status/{TransactionID}"
+	EndpointDomesticAccount        = "GET /banking/corporates/transfers/v2/domestic/beneficiary-banks/{BeneficiaryBankCode}/accounts/{BeneficiaryAccountNumber}"
+	EndpointFireInquiryAccount     = "POST /fire/accounts"
+	EndpointFireTransferToAccount  = "POST /fire/transactions/to-account"
+	EndpointFireCashTransfer       = "POST /fire/transactions/cash-transfer"
+	EndpointFireAmendCashTransfer  = "PUT /fire/transactions/cash-transfer/amend"
+	EndpointFireCancelCashTransfer = "PUT /fire/transactions/cash-transfer/cancel"
+	EndpointFireInquiryTransaction = "POST /fire/transactions"
+	EndpointFireInquiryBalance     = "POST /fire/accounts/balance"
+)
+
+// Default credentials of the mock
+const (
+	DefaultClientID     = "mock-client-id"
+	DefaultClientSecret = "mock-client-secret"
+	DefaultAPIKey       = "mock-api-key"
+	DefaultAPISecret    = "mock-api-secret"
+	DefaultCorporateID  = "BCAAPI2016"
+)
+
+// Config is config of the mock, zero fields take the defaults
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	APIKey       string
+	APISecret    string
+	CorporateID  string
+
+	// TokenTTL is lifetime of issued access tokens, default is bca.DefaultVATokenTTL
+	TokenTTL time.Duration
+	// MaxClockSkew is maximum difference between X-BCA-Timestamp and the server time, default is bca.DefaultMaxClockSkew
+	MaxClockSkew time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.ClientID == "" {
+		c.ClientID = DefaultClientID
+	}
+	if c.ClientSecret == "" {
+		c.ClientSecret = DefaultClientSecret
+	}
+	if c.APIKey == "" {
+		c.APIKey = DefaultAPIKey
+	}
+	if c.APISecret == "" {
+		c.APISecret = DefaultAPISecret
+	}
+	if c.CorporateID == "" {
+		c.CorporateID = DefaultCorporateID
+	}
+	return c
+}
+
+// Fault is failure injected into responses of the mock
+type Fault struct {
+	// Endpoint is endpoint of the failing requests (e.g. EndpointTransfer), empty means every endpoint
+	Endpoint string
+	// Times is number of failing requests, zero means every request until ClearFaults
+	Times int
+	// HTTPStatus is status of the failure response, default is 500
+	HTTPStatus int
+	// Error is BCA error response, it is sent when Body is empty
+	Error bca.Error
+	// Body is raw response body, e.g. HTML page of a gateway
+	Body string
+	// Delay delays the response, e.g. to make the client time out
+	Delay time.Duration
+	// AfterProcessing processes the request before the failure is returned, e.g. transfer is booked but its response is lost
+	AfterProcessing bool
+}
+
+// Account is account served by the mock
+type Account struct {
+	AccountNumber string
+	Name          string
+	Currency      string // default is IDR
+	Balance       bca.Amount
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, params []string)
+
+type route struct {
+	endpoint string
+	handle   handlerFunc
+}
+
+// Server is fake BCA API server
+type Server struct {
+	*httptest.Server
+
+	config    Config
+	signature bca.VASignature
+	routes    []route
+
+	mutex     sync.Mutex
+	tokens    *bca.VATokenIssuer
+	faults    []*Fault
+	accounts  map[string]*account
+	transfers map[transferKey]bca.TransferStatusResponse
+	sequence  int
+
+	domesticAccounts map[domesticAccountKey]string // name by bank code and account number
+
+	fireTransfers map[string]*fireTransfer // by FormNumber
+	cashTransfers map[string]*cashTransfer // by ReferenceNumber
+}
+
+type domesticAccountKey struct {
+	BankCode      string
+	AccountNumber string
+}
+
+type transferKey struct {
+	TransactionDate string
+	TransactionID   string
+}
+
+// NewServer start new fake BCA API server, Close it when done
+func NewServer(config Config) *Server {
+	s := NewUnstartedServer(config)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer return new fake BCA API server which is not started yet, e.g. to use TLS
+func NewUnstartedServer(config Config) *Server {
+	config = config.withDefaults()
+	s := &Server{
+		config: config,
+		signature: bca.VASignature{
+			APIKey:       config.APIKey,
+			APISecret:    config.APISecret,
+			MaxClockSkew: config.MaxClockSkew,
+		},
+		tokens:    bca.NewVATokenIssuer(config.ClientID, config.ClientSecret, config.TokenTTL),
+		accounts:  map[string]*account{},
+		transfers: map[transferKey]bca.TransferStatusResponse{},
+
+		domesticAccounts: map[domesticAccountKey]string{},
+
+		fireTransfers: map[string]*fireTransfer{},
+		cashTransfers: map[string]*cashTransfer{},
+	}
+	s.routes = []route{
+		{EndpointToken, s.serveToken},
+		{EndpointBalance, s.serveBalance},
+		{EndpointStatement, s.serveStatement},
+		{EndpointTransfer, s.serveTransfer},
+		{EndpointTransferDomestic, s.serveTransferDomestic},
+		{EndpointTransferStatus, s.serveTransferStatus},
+		{EndpointDomesticAccount, s.serveDomesticAccount},
+		{EndpointFireInquiryAccount, s.serveFireInquiryAccount},
+		{EndpointFireTransferToAccount, s.serveFireTransferToAccount},
+		{EndpointFireCashTransfer, s.serveFireCashTransfer},
+		{EndpointFireAmendCashTransfer, s.serveFireAmendCashTransfer},
+		{EndpointFireCancelCashTransfer, s.serveFireCancelCashTransfer},
+		{EndpointFireInquiryTransaction, s.serveFireInquiryTransaction},
+		{EndpointFireInquiryBalance, s.serveFireInquiryBalance},
+	}
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+// ClientConfig return bca.Config to access the mock
+func (s *Server) ClientConfig() bca.Config {
+	return bca.Config{
+		ClientID:     s.config.ClientID,
+		ClientSecret: s.config.ClientSecret,
+		APIKey:       s.config.APIKey,
+		APISecret:    s.config.APISecret,
+		URL:          s.URL,
+		CorporateID:  s.config.CorporateID,
+		OriginHost:   "localhost",
+		ChannelID:    "95051",
+		CredentialID: s.config.CorporateID,
+	}
+}
+
+// AddAccount add or replace account served by the mock, Balance is its opening balance
+func (s *Server) AddAccount(a Account) {
+	if a.Currency == "" {
+		a.Currency = "IDR"
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.accounts[a.AccountNumber] = &account{Account: a}
+}
+
+// Account return account of accountNumber with its current balance
+func (s *Server) Account(accountNumber string) (Account, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	account, ok := s.accounts[accountNumber]
+	if !ok {
+		return Account{}, false
+	}
+	return account.Account, true
+}
+
+// AddDomesticAccount add or replace account of another bank, its name is returned by domestic account inquiry
+func (s *Server) AddDomesticAccount(bankCode, accountNumber, name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.domesticAccounts[domesticAccountKey{bankCode, accountNumber}] = name
+}
+
+// ExpireTokens expire every issued access token, the next requests bearing them are refused with ESB-14-009
+func (s *Server) ExpireTokens() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tokens = bca.NewVATokenIssuer(s.config.ClientID, s.config.ClientSecret, s.config.TokenTTL)
+}
+
+// Inject add fault into responses of the mock, faults are applied in order they are injected
+func (s *Server) Inject(fault Fault) {
+	if fault.HTTPStatus == 0 {
+		fault.HTTPStatus = http.StatusInternalServerError
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults remove every injected fault
+func (s *Server) ClearFaults() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.faults = nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, params, ok := s.route(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, bca.ErrCodeServiceNotFound, "Service tidak ada", "Service doesn't exist")
+		return
+	}
+
+	fault := s.takeFault(route.endpoint)
+	if fault == nil {
+		s.serve(w, r, route, params)
+		return
+	}
+
+	if fault.AfterProcessing {
+		s.serve(httptest.NewRecorder(), r, route, params)
+	}
+	time.Sleep(fault.Delay)
+	if fault.Body != "" {
+		w.WriteHeader(fault.HTTPStatus)
+		_, _ = w.Write([]byte(fault.Body))
+		return
+	}
+	writeJSON(w, fault.HTTPStatus, fault.Error)
+}
+
+// serve authenticate the request, except token request, and pass it to route
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, route route, params []string) {
+	if route.endpoint == EndpointToken {
+		route.handle(w, r, params)
+		return
+	}
+
+	s.mutex.Lock()
+	tokens := s.tokens
+	s.mutex.Unlock()
+
+	if err := tokens.Authenticate(r); err != nil {
+		writeError(w, http.StatusUnauthorized, bca.ErrCodeUnauthorized, "Tidak berhak", "Unauthorized")
+		return
+	}
+	s.signature.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route.handle(w, r, params)
+	})).ServeHTTP(w, r)
+}
+
+// route find route of r, return values of its path parameters
+func (s *Server) route(r *http.Request) (route, []string, bool) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for _, route := range s.routes {
+		method, path := splitEndpoint(route.endpoint)
+		if method != r.Method {
+			continue
+		}
+		if params, ok := matchPath(strings.Split(strings.Trim(path, "/"), "/"), segments); ok {
+			return route, params, true
+		}
+	}
+	return route{}, nil, false
+}
+
+func (s *Server) takeFault(endpoint string) *Fault {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, fault := range s.faults {
+		if fault.Endpoint != "" && fault.Endpoint != endpoint {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request, params []string) {
+	s.mutex.Lock()
+	tokens := s.tokens
+	s.mutex.Unlock()
+
+	tokens.ServeHTTP(w, r)
+}
+
+func splitEndpoint(endpoint string) (method, path string) {
+	i := strings.IndexByte(endpoint, ' ')
+	return endpoint[:i], endpoint[i+1:]
+}
+
+// matchPath match path segments against pattern segments, return values of {parameter} segments
+func matchPath(pattern, segments []string) ([]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	var params []string
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			params = append(params, segments[i])
+			continue
+		}
+		if p != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// decodeRequest decode JSON body of r into dtoReq, respond ESB-14-016 if it is invalid
+func decodeRequest(w http.ResponseWriter, r *http.Request, dtoReq interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dtoReq); err != nil {
+		writeError(w, http.StatusBadRequest, bca.ErrCodeInvalidJSON, "Format JSON tidak valid", "Invalid JSON format")
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, status int, errorCode, indonesian, english string) {
+	writeJSON(w, status, bca.Error{ErrorCode: errorCode, ErrorMessage: bca.ErrorLang{Indonesian: indonesian, English: english}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
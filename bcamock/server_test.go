@@ -0,0 +1,184 @@
+package bcamock_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/purwaren/bca-api"
+	"github.com/purwaren/bca-api/bcamock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	srv := bcamock.NewServer(bcamock.Config{})
+	defer srv.Close()
+
+	srv.AddAccount(bcamock.Account{AccountNumber: "0201245680", Name: "BCA API", Balance: bca.NewAmount(1000000, 0)})
+	srv.AddAccount(bcamock.Account{AccountNumber: "0201245681", Name: "Tester"})
+	srv.AddAccount(bcamock.Account{AccountNumber: "0106666011", Name: "STEVEN"})
+
+	config := srv.ClientConfig()
+	config.RetryPolicy = bca.RetryPolicy{InitialBackoff: time.Millisecond}
+	b := bca.New(config)
+	ctx := context.Background()
+
+	t.Run("balance", func(t *testing.T) {
+		dtoResp, err := b.BankingGetBalance(ctx, bca.BalanceInfoRequest{AccountNumber: "0201245680,0201245689"})
+		require.NoError(t, err)
+		require.Len(t, dtoResp.AccountDetailDataSuccess, 1)
+		require.Equal(t, bca.NewAmount(1000000, 0), dtoResp.AccountDetailDataSuccess[0].Balance)
+		require.Len(t, dtoResp.AccountErrors(), 1)
+		require.Equal(t, "0201245689", dtoResp.AccountErrors()[0].AccountNumber)
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		dtoResp, err := b.BankingFundTransfer(ctx, bca.FundTransferRequest{
+			SourceAccountNumber:      "0201245680",
+			TransactionID:            "00000001",
+			TransactionDate:          "2016-01-30",
+			ReferenceID:              "12345/PO/2016",
+			CurrencyCode:             "IDR",
+			Amount:                   bca.NewAmount(100000, 0),
+			BeneficiaryAccountNumber: "0201245681",
+		})
+		require.NoError(t, err)
+		require.Equal(t, bcamock.StatusSuccess, dtoResp.Status)
+
+		statusResp, err := b.BankingGetTransferStatus(ctx, bca.TransferStatusRequest{
+			TransactionID: "00000001", TransactionDate: "2016-01-30", TransferType: bca.TransferTypeBCA,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "0201245681", statusResp.BeneficiaryAccountNumber)
+		require.Equal(t, bca.NewAmount(100000, 0), statusResp.Amount)
+
+		_, err = b.BankingGetTransferStatus(ctx, bca.TransferStatusRequest{
+			TransactionID: "00000002", TransactionDate: "2016-01-30", TransferType: bca.TransferTypeBCA,
+		})
+		require.True(t, bca.IsTransactionNotFound(err))
+	})
+
+	t.Run("domestic transfer", func(t *testing.T) {
+		dtoResp, err := b.BankingFundTransferDomestic(ctx, bca.FundTransferDomesticRequest{
+			TransactionID:            "00000003",
+			TransactionDate:          "2018-05-03",
+			ReferenceID:              "12345/PO/2016",
+			SourceAccountNumber:      "0201245680",
+			BeneficiaryAccountNumber: "0201245501",
+			BeneficiaryBankCode:      "BRONINJA",
+			BeneficiaryName:          "Tester",
+			Amount:                   bca.NewAmount(100000, 0),
+			TransferType:             bca.TransferTypeONL,
+			CurrencyCode:             "IDR",
+		})
+		require.NoError(t, err)
+		require.Equal(t, bcamock.StatusSuccess, dtoResp.Status)
+		require.NotEmpty(t, dtoResp.PPUNumber)
+	})
+
+	t.Run("invalid account", func(t *testing.T) {
+		_, err := b.BankingFundTransfer(ctx, bca.FundTransferRequest{
+			SourceAccountNumber:      "0201245689",
+			TransactionID:            "00000004",
+			TransactionDate:          "2016-01-30",
+			ReferenceID:              "12345/PO/2016",
+			CurrencyCode:             "IDR",
+			Amount:                   bca.NewAmount(100000, 0),
+			BeneficiaryAccountNumber: "0201245681",
+		})
+		apiErr, ok := bca.AsAPIError(err)
+		require.True(t, ok)
+		require.Equal(t, bca.ErrCodeInvalidAccount, apiErr.ErrorCode)
+	})
+
+	t.Run("FIRe inquiry account", func(t *testing.T) {
+		dtoResp, err := b.FireInquiryAccount(ctx, bca.InquiryAccountRequest{
+			Authentication: bca.Authentication{CorporateID: "DUMMYI", AccessCode: "Kw5oTuF12dseSH44Y8ww", BranchCode: "BCA001", UserID: "BCAUSERID", LocalID: "40115"},
+			BeneficiaryDetails: bca.InquiryAccountRequestBeneficiaryDetails{
+				BankCodeType:  "BIC",
+				BankCodeValue: "CENAIDJAXXX",
+				AccountNumber: "0106666011",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "STEVEN", dtoResp.BeneficiaryDetails.ServerBeneAccountName)
+		require.Equal(t, bcamock.FireStatusSuccess, dtoResp.StatusTransaction)
+	})
+
+	t.Run("expired token is refreshed", func(t *testing.T) {
+		srv.ExpireTokens()
+		_, err := b.BankingGetBalance(ctx, bca.BalanceInfoRequest{AccountNumber: "0201245680"})
+		require.NoError(t, err)
+	})
+
+	t.Run("injected fault", func(t *testing.T) {
+		srv.Inject(bcamock.Fault{Endpoint: bcamock.EndpointBalance, Times: 1, HTTPStatus: http.StatusBadGateway, Body: "<html>502 Bad Gateway</html>"})
+		_, err := b.BankingGetBalance(ctx, bca.BalanceInfoRequest{AccountNumber: "0201245680"})
+		require.NoError(t, err, "gateway error is retried")
+
+		srv.Inject(bcamock.Fault{
+			Endpoint:   bcamock.EndpointTransfer,
+			HTTPStatus: http.StatusBadRequest,
+			Error:      bca.Error{ErrorCode: bca.ErrCodeInsufficientFunds, ErrorMessage: bca.ErrorLang{Indonesian: "Saldo tidak cukup", English: "Insufficient funds"}},
+		})
+		defer srv.ClearFaults()
+		_, err = b.BankingFundTransfer(ctx, bca.FundTransferRequest{
+			SourceAccountNumber:      "0201245680",
+			TransactionID:            "00000005",
+			TransactionDate:          "2016-01-30",
+			ReferenceID:              "12345/PO/2016",
+			CurrencyCode:             "IDR",
+			Amount:                   bca.NewAmount(100000, 0),
+			BeneficiaryAccountNumber: "0201245681",
+		})
+		require.True(t, bca.IsInsufficientFunds(err))
+	})
+
+	t.Run("fault after processing", func(t *testing.T) {
+		srv.Inject(bcamock.Fault{Endpoint: bcamock.EndpointTransfer, Times: 1, HTTPStatus: http.StatusGatewayTimeout, Body: "timeout", AfterProcessing: true})
+
+		config := srv.ClientConfig()
+		config.AtMostOnceTransfer = true
+		dtoResp, err := bca.New(config).BankingFundTransfer(ctx, bca.FundTransferRequest{
+			SourceAccountNumber:      "0201245680",
+			TransactionID:            "00000006",
+			TransactionDate:          "2016-01-30",
+			ReferenceID:              "12345/PO/2016",
+			CurrencyCode:             "IDR",
+			Amount:                   bca.NewAmount(100000, 0),
+			BeneficiaryAccountNumber: "0201245681",
+		})
+		require.NoError(t, err, "booked transfer is resolved by status inquiry")
+		require.Equal(t, bcamock.StatusSuccess, dtoResp.Status)
+	})
+}
+
+func TestServer_signature(t *testing.T) {
+	srv := bcamock.NewServer(bcamock.Config{})
+	defer srv.Close()
+	srv.AddAccount(bcamock.Account{AccountNumber: "0201245680"})
+
+	tests := []struct {
+		name         string
+		modify       func(*bca.Config)
+		expectedCode string
+	}{
+		{"invalid API key", func(c *bca.Config) { c.APIKey = "other" }, bca.ErrCodeInvalidAPIKey},
+		{"invalid API secret", func(c *bca.Config) { c.APISecret = "other" }, bca.ErrCodeHMACMismatch},
+		{"invalid client", func(c *bca.Config) { c.ClientSecret = "other" }, bca.ErrCodeInvalidClient},
+		{"invalid CorporateID", func(c *bca.Config) { c.CorporateID = "OTHER" }, bca.ErrCodeInvalidCorporateID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := srv.ClientConfig()
+			tt.modify(&config)
+			config.RetryPolicy = bca.RetryPolicy{Attempts: 1}
+
+			_, err := bca.New(config).BankingGetBalance(context.Background(), bca.BalanceInfoRequest{AccountNumber: "0201245680"})
+			apiErr, ok := bca.AsAPIError(err)
+			require.True(t, ok, "%+v", err)
+			require.Equal(t, tt.expectedCode, apiErr.ErrorCode)
+		})
+	}
+}
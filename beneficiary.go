@@ -0,0 +1,94 @@
+package bca
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultBeneficiaryNameThreshold is default minimum similarity of BeneficiaryName to the beneficiary account name
+const DefaultBeneficiaryNameThreshold = 0.8
+
+// normalizeName uppercase name, drop punctuation and collapse whitespace, e.g. "  Tester,  S.E. " is "TESTER SE"
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// nameSimilarity return similarity (0 to 1) of beneficiaryName to accountName, based on Levenshtein distance
+// of their normalized forms, in the given and in sorted word order. accountName is truncated to beneficiaryName
+// filling maxRunes, as BCA limits BeneficiaryName of domestic transfer while account names may be longer.
+// Empty accountName matches nothing.
+func nameSimilarity(beneficiaryName, accountName string, maxRunes int) float64 {
+	a, b := normalizeName(beneficiaryName), normalizeName(accountName)
+	if b == "" {
+		return 0
+	}
+	if n := len([]rune(a)); n >= maxRunes && len([]rune(b)) > n {
+		b = string([]rune(b)[:n])
+	}
+
+	similarity := levenshteinSimilarity([]rune(a), []rune(b))
+	if sorted := levenshteinSimilarity([]rune(sortWords(a)), []rune(sortWords(b))); sorted > similarity {
+		similarity = sorted
+	}
+	return similarity
+}
+
+// sortWords return words of name sorted, e.g. "SANTOSO BUDI" is "BUDI SANTOSO"
+func sortWords(name string) string {
+	words := strings.Fields(name)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// levenshteinSimilarity return 1 - Levenshtein distance of a and b relative to the longer one
+func levenshteinSimilarity(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein return minimum number of rune insertions, deletions and substitutions turning a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
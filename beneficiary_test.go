@@ -0,0 +1,30 @@
+package bca
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		beneficiaryName string
+		accountName     string
+		expected        float64
+	}{
+		{"Tester", "TESTER", 1},
+		{" tester,  s.e. ", "TESTER SE", 1},
+		{"Tester", "Testor", 1 - 1.0/6},
+		{"Tester", "Budi Santoso", 1 - 10.0/12},
+		{"Budi Santoso Wijay", "BUDI SANTOSO WIJAYAKUSUMA", 1},     // truncated to 18 runes
+		{"Budi Santoso", "BUDI SANTOSO WIJAYAKUSUMA", 1 - 13.0/25}, // not truncated
+		{"Budi S. Wijayakus.", "BUDI S WIJAYAKUSUMA", 1 - 3.0/19},  // not truncated, 16 runes when normalized
+		{"Santoso Budi", "BUDI SANTOSO", 1},                        // word order swapped
+		{"Santoso Budi", "BUDI SANTOSA", 1 - 1.0/12},
+		{"Tester", "", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		require.InDelta(t, tt.expected, nameSimilarity(tt.beneficiaryName, tt.accountName, maxBeneficiaryNameRunes), 1e-9, "%q %q", tt.beneficiaryName, tt.accountName)
+	}
+}
@@ -1,5 +1,12 @@
 package bca
 
+import (
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
 // Config is config to access BCA API
 type Config struct {
 	ClientID     string
@@ -13,7 +20,88 @@ type Config struct {
 	ChannelID    string
 	CredentialID string
 
+	// Fire is FIRe credentials filling empty Authentication of FIRe requests, optional
+	Fire FireConfig
+
+	// HTTPClient sends requests to BCA, default is pooled client of go-cleanhttp.
+	// Set its Transport to e.g. bcacassette.Cassette to record or replay the exchanges.
+	HTTPClient *http.Client
+
+	// TokenStore keeps access token, default is in process memory.
+	// Use a shared store (e.g. FileTokenStore on a shared volume) to share one access token between replicas.
+	TokenStore TokenStore
+	// TokenRefreshBefore is how long before its expiry an access token is refreshed, default is 1 minute
+	TokenRefreshBefore time.Duration
+
+	// RetryPolicy controls retrying failed operation, zero fields are taken from DefaultRetryPolicy
+	RetryPolicy RetryPolicy
+
+	// AtMostOnceTransfer resolve ambiguous transfer failure (e.g. timeout) by transfer status inquiry,
+	// the transfer is resubmitted only when BCA confirms it is unknown
+	AtMostOnceTransfer bool
+
+	// VerifyDomesticBeneficiary inquire beneficiary account name before domestic transfer,
+	// the transfer is aborted with *BeneficiaryMismatchError when BeneficiaryName does not match it
+	VerifyDomesticBeneficiary bool
+	// BeneficiaryNameThreshold is minimum similarity (0 to 1) of BeneficiaryName to the account name,
+	// default is DefaultBeneficiaryNameThreshold
+	BeneficiaryNameThreshold float64
+
+	// Ledger records outgoing transfers and rejects reused TransactionID before it is sent, optional
+	Ledger TransferLedger
+
+	// TransactionIDGenerator generates TransactionID and TransactionDate of transfers when they are left empty, optional.
+	// Without it, transfers having empty TransactionID or TransactionDate fail with ErrNoTransactionIDGenerator.
+	TransactionIDGenerator *TransactionIDGenerator
+
 	LogLevel int
 
 	LogPath string
 }
+
+// Validate validate the config, e.g. at startup. Fire is validated when any of its fields is set.
+func (c Config) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.ClientID, validation.Required),
+		validation.Field(&c.ClientSecret, validation.Required),
+		validation.Field(&c.APIKey, validation.Required),
+		validation.Field(&c.APISecret, validation.Required),
+		validation.Field(&c.URL, validation.Required),
+		validation.Field(&c.CorporateID, validation.Required),
+		validation.Field(&c.Fire),
+	)
+}
+
+// FireConfig is FIRe credentials given by BCA, they are sent as Authentication of FIRe requests
+type FireConfig struct {
+	CorporateID string
+	AccessCode  string
+	BranchCode  string
+	UserID      string
+	LocalID     string
+}
+
+// Validate validate FIRe credentials are complete, empty FireConfig is valid
+func (c FireConfig) Validate() error {
+	if c == (FireConfig{}) {
+		return nil
+	}
+	return c.authentication().Validate()
+}
+
+func (c FireConfig) authentication() Authentication {
+	return Authentication{
+		CorporateID: c.CorporateID,
+		AccessCode:  c.AccessCode,
+		BranchCode:  c.BranchCode,
+		UserID:      c.UserID,
+		LocalID:     c.LocalID,
+	}
+}
+
+// fill fill empty authentication of FIRe request
+func (c FireConfig) fill(authentication *Authentication) {
+	if *authentication == (Authentication{}) {
+		*authentication = c.authentication()
+	}
+}
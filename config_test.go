@@ -0,0 +1,49 @@
+package bca_test
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/purwaren/bca-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	givenConfig := func(fire bca.FireConfig) bca.Config {
+		return bca.Config{
+			ClientID:     "client-id",
+			ClientSecret: "client-secret",
+			APIKey:       "api-key",
+			APISecret:    "api-secret",
+			URL:          "https://sandbox.bca.co.id",
+			CorporateID:  "BCAAPI2016",
+			Fire:         fire,
+		}
+	}
+	completeFire := bca.FireConfig{CorporateID: "DUMMYI", AccessCode: "Kw5oTuF12dseSH44Y8ww", BranchCode: "BCA001", UserID: "BCAUSERID", LocalID: "40115"}
+
+	tests := []struct {
+		name           string
+		config         bca.Config
+		expectedFields []string
+	}{
+		{"without FIRe", givenConfig(bca.FireConfig{}), nil},
+		{"with FIRe", givenConfig(completeFire), nil},
+		{"incomplete FIRe", givenConfig(bca.FireConfig{CorporateID: "DUMMYI", AccessCode: "Kw5oTuF12dseSH44Y8ww"}), []string{"Fire.BranchCode", "Fire.LocalID", "Fire.UserID"}},
+		{"missing credentials", bca.Config{Fire: completeFire}, []string{"APIKey", "APISecret", "ClientID", "ClientSecret", "CorporateID", "URL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedFields == nil {
+				require.NoError(t, err)
+				return
+			}
+			errs, ok := err.(validation.Errors)
+			require.True(t, ok, "%v", err)
+			var fields []string
+			collectFields(&fields, "", errs)
+			require.ElementsMatch(t, tt.expectedFields, fields)
+		})
+	}
+}
@@ -1,6 +1,55 @@
 package bca
 
-import validation "github.com/go-ozzo/ozzo-validation"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/juju/errors"
+)
+
+// Represent TransferType of domestic transfer
+const (
+	TransferTypeLLG = "LLG" // SKN
+	TransferTypeRTG = "RTG" // RTGS
+	TransferTypeONL = "ONL" // online transfer
+	TransferTypeBIF = "BIF" // BI-FAST
+)
+
+// maxBeneficiaryNameRunes is maximum length of BeneficiaryName of domestic transfer
+const maxBeneficiaryNameRunes = 18
+
+var (
+	bcaAccountNumberRule = validation.Match(regexp.MustCompile(`^[0-9]{10}$`)).Error("must be 10 digits")
+	accountNumberRule    = validation.Match(regexp.MustCompile(`^[0-9]{1,34}$`)).Error("must be up to 34 digits")
+	transactionIDRule    = validation.Match(regexp.MustCompile(`^[0-9]{8}$`)).Error("must be 8 digits")
+	dateRule             = validation.Date(transactionDateLayout).Error("must be a date in yyyy-MM-dd format")
+	remarkRule           = validation.RuneLength(0, 18)
+	maxAmount            = NewAmount(9999999999999, 99) // Numeric(13.2)
+)
+
+// amountRule validate positive amount of currencyCode
+func amountRule(currencyCode string) validation.Rule {
+	return validation.By(func(value interface{}) error {
+		amount, _ := value.(Amount)
+		if amount > maxAmount {
+			return errors.New("must be no greater than " + maxAmount.String())
+		}
+		if err := amount.Validate(currencyCode); err != nil {
+			return errors.New(err.Error())
+		}
+		return nil
+	})
+}
+
+// maskSecret mask non-empty secret, so it is not logged
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "******"
+}
 
 // === AUTH ===
 
@@ -32,19 +81,45 @@ type ErrorLang struct {
 // AccountBalance represents account balance information
 type AccountBalance struct {
 	AccountNumber    string
-	Currency         string  `json:",omitempty"`
-	Balance          float64 `json:",string"`
-	AvailableBalance float64 `json:",string"`
-	FloatAmount      float64 `json:",string"`
-	HoldAmount       float64 `json:",string"`
-	Plafon           float64 `json:",string"`
-	Indonesian       string  `json:",omitempty"`
-	English          string  `json:",omitempty"`
+	Currency         string `json:",omitempty"`
+	Balance          Amount
+	AvailableBalance Amount
+	FloatAmount      Amount
+	HoldAmount       Amount
+	Plafon           Amount
+	Indonesian       string `json:",omitempty"`
+	English          string `json:",omitempty"`
 }
 
-// BalanceInfoRequest represents account balance information request message
+// MaxBalanceInfoAccounts is maximum number of accounts BCA accepts in a single balance information request
+const MaxBalanceInfoAccounts = 20
+
+// BalanceInfoRequest represents account balance information request message.
+// AccountNumber may contain comma-separated account numbers, AccountNumbers is appended to it.
 type BalanceInfoRequest struct {
-	AccountNumber string
+	AccountNumber  string
+	AccountNumbers []string
+}
+
+func (m BalanceInfoRequest) accountNumbers() []string {
+	var accountNumbers []string
+	seen := map[string]bool{}
+	for _, accountNumber := range append(strings.Split(m.AccountNumber, ","), m.AccountNumbers...) {
+		accountNumber = strings.TrimSpace(accountNumber)
+		if accountNumber == "" || seen[accountNumber] {
+			continue
+		}
+		seen[accountNumber] = true
+		accountNumbers = append(accountNumbers, accountNumber)
+	}
+	return accountNumbers
+}
+
+// Validate validate the request before it is sent
+func (m BalanceInfoRequest) Validate() error {
+	return validation.Errors{
+		"AccountNumbers": validation.Validate(m.accountNumbers(), validation.Required, validation.Each(bcaAccountNumberRule)),
+	}.Filter()
 }
 
 // BalanceInfoResponse represents account balance information response message
@@ -54,23 +129,61 @@ type BalanceInfoResponse struct {
 	AccountDetailDataFailed  []AccountBalance `json:",omitempty"`
 }
 
+// AccountErrors return failed accounts of balance information response as typed errors
+func (m BalanceInfoResponse) AccountErrors() []*AccountBalanceError {
+	var accountErrs []*AccountBalanceError
+	for _, failed := range m.AccountDetailDataFailed {
+		accountErrs = append(accountErrs, &AccountBalanceError{
+			AccountNumber: failed.AccountNumber,
+			ErrorMessage:  ErrorLang{Indonesian: failed.Indonesian, English: failed.English},
+		})
+	}
+	return accountErrs
+}
+
+// AccountBalanceError represents failed balance information of a single account
+type AccountBalanceError struct {
+	AccountNumber string
+	ErrorMessage  ErrorLang
+}
+
+func (e *AccountBalanceError) Error() string {
+	return fmt.Sprintf("balance information of account %s failed: %s", e.AccountNumber, e.ErrorMessage.English)
+}
+
 // AccountStatement represents account statement information
 type AccountStatement struct {
 	TransactionDate   string
 	BranchCode        string
 	TransactionType   string
-	TransactionAmount float64 `json:",string"`
+	TransactionAmount Amount
 	TransactionName   string
 	Trailer           string
 }
 
+// AccountStatementRequest represents account statement request message
+type AccountStatementRequest struct {
+	AccountNumber string
+	StartDate     string
+	EndDate       string
+}
+
+// Validate validate the request before it is sent
+func (m AccountStatementRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.AccountNumber, validation.Required, bcaAccountNumberRule),
+		validation.Field(&m.StartDate, validation.Required, dateRule),
+		validation.Field(&m.EndDate, validation.Required, dateRule),
+	)
+}
+
 // AccountStatementResponse represents account statement response message
 type AccountStatementResponse struct {
 	Error
 	StartDate    string
 	EndDate      string
 	Currency     string
-	StartBalance float64 `json:",string"`
+	StartBalance Amount
 	Data         []AccountStatement
 }
 
@@ -82,12 +195,28 @@ type FundTransferRequest struct {
 	TransactionDate          string
 	ReferenceID              string
 	CurrencyCode             string
-	Amount                   float64 `json:",string"`
+	Amount                   Amount
 	BeneficiaryAccountNumber string
 	Remark1                  string
 	Remark2                  string
 }
 
+// Validate validate the request before it is sent
+func (m FundTransferRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.CorporateID, validation.Required, validation.Length(1, 10)),
+		validation.Field(&m.SourceAccountNumber, validation.Required, bcaAccountNumberRule),
+		validation.Field(&m.TransactionID, validation.Required, transactionIDRule),
+		validation.Field(&m.TransactionDate, validation.Required, dateRule),
+		validation.Field(&m.ReferenceID, validation.Required, validation.RuneLength(1, 15)),
+		validation.Field(&m.CurrencyCode, validation.Required, validation.In("IDR")),
+		validation.Field(&m.Amount, validation.Required, amountRule(m.CurrencyCode)),
+		validation.Field(&m.BeneficiaryAccountNumber, validation.Required, bcaAccountNumberRule),
+		validation.Field(&m.Remark1, remarkRule),
+		validation.Field(&m.Remark2, remarkRule),
+	)
+}
+
 // FundTransferResponse represents fund transfer response message
 type FundTransferResponse struct {
 	Error
@@ -106,7 +235,7 @@ type FundTransferDomesticRequest struct {
 	BeneficiaryAccountNumber string
 	BeneficiaryBankCode      string
 	BeneficiaryName          string
-	Amount                   float64 `json:",string"`
+	Amount                   Amount
 	TransferType             string
 	BeneficiaryCustType      string
 	BeneficiaryCustResidence string
@@ -115,6 +244,56 @@ type FundTransferDomesticRequest struct {
 	Remark2                  string
 }
 
+// Validate validate the request before it is sent.
+// BeneficiaryCustType (1 personal, 2 corporate, 3 government) and BeneficiaryCustResidence (1 resident, 2 non resident)
+// are required for LLG and RTG.
+func (m FundTransferDomesticRequest) Validate() error {
+	custRules := func(values ...interface{}) []validation.Rule {
+		if m.TransferType == TransferTypeLLG || m.TransferType == TransferTypeRTG {
+			return []validation.Rule{validation.Required, validation.In(values...)}
+		}
+		return []validation.Rule{validation.In(values...)}
+	}
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.TransactionID, validation.Required, transactionIDRule),
+		validation.Field(&m.TransactionDate, validation.Required, dateRule),
+		validation.Field(&m.ReferenceID, validation.Required, validation.RuneLength(1, 15)),
+		validation.Field(&m.SourceAccountNumber, validation.Required, bcaAccountNumberRule),
+		validation.Field(&m.BeneficiaryAccountNumber, validation.Required, accountNumberRule),
+		validation.Field(&m.BeneficiaryBankCode, validation.Required, validation.Length(1, 8)),
+		validation.Field(&m.BeneficiaryName, validation.Required, validation.RuneLength(1, maxBeneficiaryNameRunes)),
+		validation.Field(&m.Amount, validation.Required, amountRule(m.CurrencyCode)),
+		validation.Field(&m.TransferType, validation.Required, validation.In(TransferTypeLLG, TransferTypeRTG, TransferTypeONL, TransferTypeBIF)),
+		validation.Field(&m.BeneficiaryCustType, custRules("1", "2", "3")...),
+		validation.Field(&m.BeneficiaryCustResidence, custRules("1", "2")...),
+		validation.Field(&m.CurrencyCode, validation.Required, validation.In("IDR")),
+		validation.Field(&m.Remark1, remarkRule),
+		validation.Field(&m.Remark2, remarkRule),
+	)
+}
+
+// DomesticAccountRequest represents interbank account name inquiry request message
+type DomesticAccountRequest struct {
+	BeneficiaryBankCode      string
+	BeneficiaryAccountNumber string
+}
+
+// Validate validate the request before it is sent
+func (m DomesticAccountRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.BeneficiaryBankCode, validation.Required, validation.Length(1, 8)),
+		validation.Field(&m.BeneficiaryAccountNumber, validation.Required, accountNumberRule),
+	)
+}
+
+// DomesticAccountResponse represents interbank account name inquiry response message
+type DomesticAccountResponse struct {
+	Error
+	BeneficiaryBankCode      string
+	BeneficiaryAccountNumber string
+	BeneficiaryName          string
+}
+
 // FundTransferDomesticResponse represents fund transfer response message
 type FundTransferDomesticResponse struct {
 	Error
@@ -125,6 +304,48 @@ type FundTransferDomesticResponse struct {
 	Status          string
 }
 
+// TransferTypeBCA is transfer type of transfer to another BCA account, used in transfer status inquiry
+const TransferTypeBCA = "BCA"
+
+// TransferStatusRequest represents transfer status inquiry request message
+type TransferStatusRequest struct {
+	TransactionID   string
+	TransactionDate string
+	TransferType    string // TransferTypeBCA or TransferType of domestic transfer (LLG, RTG, ONL)
+}
+
+// Validate validate the request before it is sent
+func (m TransferStatusRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.TransactionID, validation.Required, transactionIDRule),
+		validation.Field(&m.TransactionDate, validation.Required, dateRule),
+		validation.Field(&m.TransferType, validation.Required, validation.In(TransferTypeBCA, TransferTypeLLG, TransferTypeRTG, TransferTypeONL, TransferTypeBIF)),
+	)
+}
+
+// Represent Status of TransferStatusResponse
+const (
+	TransferStatusSuccess = "Success"
+	TransferStatusFailed  = "Failed"
+)
+
+// TransferStatusResponse represents transfer status inquiry response message
+type TransferStatusResponse struct {
+	Error
+	TransactionID            string
+	TransactionDate          string
+	TransferType             string
+	ReferenceID              string
+	SourceAccountNumber      string
+	BeneficiaryAccountNumber string
+	BeneficiaryBankCode      string
+	BeneficiaryName          string
+	Amount                   Amount
+	CurrencyCode             string
+	PPUNumber                string
+	Status                   string
+}
+
 // InquiryBillRequest represents VA inquiry bill message
 type InquiryBillRequest struct {
 	CompanyCode     string
@@ -159,7 +380,7 @@ type ReasonMessage struct {
 // DetailBill ...
 type DetailBill struct {
 	BillDescription ReasonMessage
-	BillAmount      string
+	BillAmount      Amount
 	BillNumber      string
 	BillSubCompany  string
 }
@@ -170,8 +391,8 @@ type DetailBillPayment struct {
 	Reason 			ReasonMessage
 }
 
-// InquiryBillSingleResponse ...
-type InquiryBillSingleResponse struct {
+// InquiryBillResponse represents response of VA inquiry bill message, DetailBills lists bills of multi-bill inquiry
+type InquiryBillResponse struct {
 	CompanyCode		string
 	CustomerNumber 	string
 	RequestID      	string
@@ -179,13 +400,16 @@ type InquiryBillSingleResponse struct {
 	InquiryReason  	ReasonMessage
 	CustomerName   	string
 	CurrencyCode   	string
-	TotalAmount    	string
+	TotalAmount    	Amount
 	SubCompany     	string
 	DetailBills    	[]DetailBill
 	FreeTexts 		[]ReasonMessage
 	AdditionalData	string
 }
 
+// InquiryBillSingleResponse is InquiryBillResponse of single bill
+type InquiryBillSingleResponse = InquiryBillResponse
+
 // PaymentBillRequest ...
 type PaymentBillRequest struct {
 	CompanyCode     string
@@ -194,8 +418,8 @@ type PaymentBillRequest struct {
 	ChannelType     string
 	CustomerName    string
 	CurrencyCode    string
-	PaidAmount      string
-	TotalAmount     string
+	PaidAmount      Amount
+	TotalAmount     Amount
 	SubCompany      string
 	TransactionDate string
 	Reference       string
@@ -212,10 +436,8 @@ func (m PaymentBillRequest) Validate() error {
 		validation.Field(&m.ChannelType, validation.Required),
 		validation.Field(&m.CustomerName, validation.Required),
 		validation.Field(&m.CurrencyCode, validation.Required),
-		validation.Field(&m.PaidAmount, validation.Required),
-		validation.Field(&m.TotalAmount, validation.Required),
+		validation.Field(&m.TotalAmount, validation.Min(Amount(0))), // zero for open payment
 		validation.Field(&m.TransactionDate, validation.Required),
-		validation.Field(&m.PaidAmount, validation.Required),
 		validation.Field(&m.FlagAdvice, validation.Required),
 		validation.Field(&m.SubCompany, validation.Required),
 		validation.Field(&m.Reference, validation.Required),
@@ -242,8 +464,8 @@ type PaymentBillResponse struct {
 	PaymentFlagReason ReasonMessage
 	CustomerNumber    string
 	CurrencyCode      string
-	PaidAmount        string
-	TotalAmount       string
+	PaidAmount        Amount
+	TotalAmount       Amount
 	TransactionDate   string
 	DetailBills       []DetailBillPayment
 	FreeTexts         []ReasonMessage
@@ -259,6 +481,24 @@ type Authentication struct {
 	LocalID     string
 }
 
+// Validate validate the authentication before it is sent
+func (m Authentication) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.CorporateID, validation.Required),
+		validation.Field(&m.AccessCode, validation.Required),
+		validation.Field(&m.BranchCode, validation.Required),
+		validation.Field(&m.UserID, validation.Required),
+		validation.Field(&m.LocalID, validation.Required),
+	)
+}
+
+// String return the authentication with AccessCode masked, so it is not logged
+func (m Authentication) String() string {
+	type authentication Authentication
+	m.AccessCode = maskSecret(m.AccessCode)
+	return fmt.Sprintf("%+v", authentication(m))
+}
+
 // InquiryAccountRequestBeneficiaryDetails is beneficiary details of inquiry account request
 type InquiryAccountRequestBeneficiaryDetails struct {
 	BankCodeType  string
@@ -266,6 +506,15 @@ type InquiryAccountRequestBeneficiaryDetails struct {
 	AccountNumber string
 }
 
+// Validate validate the beneficiary details before it is sent
+func (m InquiryAccountRequestBeneficiaryDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.BankCodeType, validation.Required),
+		validation.Field(&m.BankCodeValue, validation.Required),
+		validation.Field(&m.AccountNumber, validation.Required, validation.Length(1, 34)),
+	)
+}
+
 // InquiryAccountResponseBeneficiaryDetails is beneficiary details of inquiry account response
 type InquiryAccountResponseBeneficiaryDetails struct {
 	ServerBeneAccountName string
@@ -277,6 +526,14 @@ type InquiryAccountRequest struct {
 	BeneficiaryDetails InquiryAccountRequestBeneficiaryDetails
 }
 
+// Validate validate the request before it is sent
+func (m InquiryAccountRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Authentication),
+		validation.Field(&m.BeneficiaryDetails),
+	)
+}
+
 // InquiryAccountResponse represents inquiry account response message
 type InquiryAccountResponse struct {
 	Error
@@ -284,3 +541,433 @@ type InquiryAccountResponse struct {
 	StatusTransaction  string
 	StatusMessage      string
 }
+
+// SenderDetails is sender details of FIRe transfer request
+type SenderDetails struct {
+	FirstName            string
+	LastName             string
+	DateOfBirth          string // yyyy-MM-dd
+	Address1             string
+	Address2             string
+	City                 string
+	StateID              string
+	PostalCode           string
+	CountryID            string // ISO 3166-1 alpha-2, e.g. ID
+	Mobile               string
+	IdentificationType   string
+	IdentificationNumber string
+	AccountNumber        string
+}
+
+// Validate validate the sender details before it is sent
+func (m SenderDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.FirstName, validation.Required, validation.RuneLength(1, 35)),
+		validation.Field(&m.LastName, validation.RuneLength(0, 35)),
+		validation.Field(&m.DateOfBirth, dateRule),
+		validation.Field(&m.Address1, validation.Required, validation.RuneLength(1, 35)),
+		validation.Field(&m.Address2, validation.RuneLength(0, 35)),
+		validation.Field(&m.City, validation.Required, validation.RuneLength(1, 35)),
+		validation.Field(&m.CountryID, validation.Required, validation.Length(2, 2)),
+		validation.Field(&m.IdentificationType, validation.Required),
+		validation.Field(&m.IdentificationNumber, validation.Required, validation.Length(1, 35)),
+		validation.Field(&m.AccountNumber, validation.Length(0, 34)),
+	)
+}
+
+// TransferToAccountRequestBeneficiaryDetails is beneficiary details of FIRe transfer to account request
+type TransferToAccountRequestBeneficiaryDetails struct {
+	Name                 string
+	DateOfBirth          string // yyyy-MM-dd
+	Address1             string
+	Address2             string
+	City                 string
+	StateID              string
+	PostalCode           string
+	CountryID            string // ISO 3166-1 alpha-2, e.g. ID
+	Mobile               string
+	IdentificationType   string
+	IdentificationNumber string
+	NationalityID        string
+	Occupation           string
+	BankCodeType         string // e.g. BIC
+	BankCodeValue        string
+	BankCountryID        string
+	BankAddress          string
+	BankCity             string
+	AccountNumber        string
+}
+
+// Validate validate the beneficiary details before it is sent
+func (m TransferToAccountRequestBeneficiaryDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Name, validation.Required, validation.RuneLength(1, 35)),
+		validation.Field(&m.DateOfBirth, dateRule),
+		validation.Field(&m.Address1, validation.RuneLength(0, 35)),
+		validation.Field(&m.Address2, validation.RuneLength(0, 35)),
+		validation.Field(&m.CountryID, validation.Length(2, 2)),
+		validation.Field(&m.BankCodeType, validation.Required),
+		validation.Field(&m.BankCodeValue, validation.Required),
+		validation.Field(&m.BankCountryID, validation.Length(2, 2)),
+		validation.Field(&m.AccountNumber, validation.Required, validation.Length(1, 34)),
+	)
+}
+
+// TransferToAccountRequestTransactionDetails is transaction details of FIRe transfer to account request
+type TransferToAccountRequestTransactionDetails struct {
+	CurrencyID      string
+	Amount          Amount
+	PurposeCode     string
+	Description1    string
+	Description2    string
+	DetailOfCharges string // SHA or OUR
+	SourceOfFund    string
+	FormNumber      string // unique number of the transaction, used to inquire it
+}
+
+// Validate validate the transaction details before it is sent
+func (m TransferToAccountRequestTransactionDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.CurrencyID, validation.Required, validation.In("IDR")),
+		validation.Field(&m.Amount, validation.Required, amountRule(m.CurrencyID)),
+		validation.Field(&m.PurposeCode, validation.Required),
+		validation.Field(&m.Description1, validation.RuneLength(0, 35)),
+		validation.Field(&m.Description2, validation.RuneLength(0, 35)),
+		validation.Field(&m.DetailOfCharges, validation.In("SHA", "OUR")),
+		validation.Field(&m.FormNumber, validation.Required, validation.Length(1, 16)),
+	)
+}
+
+// TransferToAccountRequest represents FIRe transfer to account request message
+type TransferToAccountRequest struct {
+	Authentication     Authentication
+	SenderDetails      SenderDetails
+	BeneficiaryDetails TransferToAccountRequestBeneficiaryDetails
+	TransactionDetails TransferToAccountRequestTransactionDetails
+}
+
+// Validate validate the request before it is sent
+func (m TransferToAccountRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Authentication),
+		validation.Field(&m.SenderDetails),
+		validation.Field(&m.BeneficiaryDetails),
+		validation.Field(&m.TransactionDetails),
+	)
+}
+
+// TransferToAccountResponseBeneficiaryDetails is beneficiary details of FIRe transfer to account response
+type TransferToAccountResponseBeneficiaryDetails struct {
+	Name                  string
+	AccountNumber         string
+	ServerBeneAccountName string
+}
+
+// FireTransactionDetails is transaction details of FIRe transfer response
+type FireTransactionDetails struct {
+	CurrencyID      string
+	Amount          Amount
+	Description1    string
+	Description2    string
+	FormNumber      string
+	ReferenceNumber string // FIRe transaction number
+	ReleaseDateTime string
+}
+
+// TransferToAccountResponse represents FIRe transfer to account response message
+type TransferToAccountResponse struct {
+	Error
+	BeneficiaryDetails TransferToAccountResponseBeneficiaryDetails
+	TransactionDetails FireTransactionDetails
+	StatusTransaction  string
+	StatusMessage      string
+}
+
+// CashTransferRequestBeneficiaryDetails is beneficiary details of FIRe cash transfer request,
+// the beneficiary shows the identification to pick up the cash at BCA branch
+type CashTransferRequestBeneficiaryDetails struct {
+	Name                 string
+	DateOfBirth          string // yyyy-MM-dd
+	Address1             string
+	Address2             string
+	City                 string
+	StateID              string
+	PostalCode           string
+	CountryID            string // ISO 3166-1 alpha-2, e.g. ID
+	Mobile               string
+	IdentificationType   string
+	IdentificationNumber string
+	NationalityID        string
+	Occupation           string
+}
+
+// Validate validate the beneficiary details before it is sent
+func (m CashTransferRequestBeneficiaryDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Name, validation.Required, validation.RuneLength(1, 35)),
+		validation.Field(&m.DateOfBirth, dateRule),
+		validation.Field(&m.Address1, validation.RuneLength(0, 35)),
+		validation.Field(&m.Address2, validation.RuneLength(0, 35)),
+		validation.Field(&m.CountryID, validation.Length(2, 2)),
+		validation.Field(&m.IdentificationType, validation.Required),
+		validation.Field(&m.IdentificationNumber, validation.Required, validation.Length(1, 35)),
+	)
+}
+
+// CashTransferRequestTransactionDetails is transaction details of FIRe cash transfer request.
+// The beneficiary answers SecretQuestion to pick up the cash.
+type CashTransferRequestTransactionDetails struct {
+	CurrencyID      string
+	Amount          Amount
+	PurposeCode     string
+	Description1    string
+	Description2    string
+	DetailOfCharges string // SHA or OUR
+	SourceOfFund    string
+	FormNumber      string // unique number of the transaction, used to inquire it
+	SecretQuestion  string
+	SecretAnswer    string
+}
+
+// Validate validate the transaction details before it is sent
+func (m CashTransferRequestTransactionDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.CurrencyID, validation.Required, validation.In("IDR")),
+		validation.Field(&m.Amount, validation.Required, amountRule(m.CurrencyID)),
+		validation.Field(&m.PurposeCode, validation.Required),
+		validation.Field(&m.Description1, validation.RuneLength(0, 35)),
+		validation.Field(&m.Description2, validation.RuneLength(0, 35)),
+		validation.Field(&m.DetailOfCharges, validation.In("SHA", "OUR")),
+		validation.Field(&m.FormNumber, validation.Required, validation.Length(1, 16)),
+		validation.Field(&m.SecretQuestion, validation.Required, validation.RuneLength(1, 35)),
+		validation.Field(&m.SecretAnswer, validation.Required, validation.RuneLength(1, 35)),
+	)
+}
+
+// String return the transaction details with SecretAnswer masked, so it is not logged
+func (m CashTransferRequestTransactionDetails) String() string {
+	type transactionDetails CashTransferRequestTransactionDetails
+	m.SecretAnswer = maskSecret(m.SecretAnswer)
+	return fmt.Sprintf("%+v", transactionDetails(m))
+}
+
+// CashTransferRequest represents FIRe cash transfer request message
+type CashTransferRequest struct {
+	Authentication     Authentication
+	SenderDetails      SenderDetails
+	BeneficiaryDetails CashTransferRequestBeneficiaryDetails
+	TransactionDetails CashTransferRequestTransactionDetails
+}
+
+// Validate validate the request before it is sent
+func (m CashTransferRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Authentication),
+		validation.Field(&m.SenderDetails),
+		validation.Field(&m.BeneficiaryDetails),
+		validation.Field(&m.TransactionDetails),
+	)
+}
+
+// CashTransferResponseBeneficiaryDetails is beneficiary details of FIRe cash transfer response
+type CashTransferResponseBeneficiaryDetails struct {
+	Name string
+}
+
+// CashTransferResponse represents FIRe cash transfer response message,
+// TransactionDetails.ReferenceNumber identifies the cash transfer to amend or cancel it
+type CashTransferResponse struct {
+	Error
+	BeneficiaryDetails CashTransferResponseBeneficiaryDetails
+	TransactionDetails FireTransactionDetails
+	StatusTransaction  string
+	StatusMessage      string
+}
+
+// AmendCashTransferRequestTransactionDetails identifies amended cash transfer and carries its new secret question
+type AmendCashTransferRequestTransactionDetails struct {
+	ReferenceNumber string
+	FormNumber      string
+	SecretQuestion  string
+	SecretAnswer    string
+}
+
+// Validate validate the transaction details before it is sent
+func (m AmendCashTransferRequestTransactionDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.ReferenceNumber, validation.Required),
+		validation.Field(&m.FormNumber, validation.Required, validation.Length(1, 16)),
+		validation.Field(&m.SecretQuestion, validation.Required, validation.RuneLength(1, 35)),
+		validation.Field(&m.SecretAnswer, validation.Required, validation.RuneLength(1, 35)),
+	)
+}
+
+// String return the transaction details with SecretAnswer masked, so it is not logged
+func (m AmendCashTransferRequestTransactionDetails) String() string {
+	type transactionDetails AmendCashTransferRequestTransactionDetails
+	m.SecretAnswer = maskSecret(m.SecretAnswer)
+	return fmt.Sprintf("%+v", transactionDetails(m))
+}
+
+// AmendCashTransferRequest represents FIRe amendment of unpaid cash transfer request message,
+// BeneficiaryDetails replaces beneficiary of the cash transfer
+type AmendCashTransferRequest struct {
+	Authentication     Authentication
+	BeneficiaryDetails CashTransferRequestBeneficiaryDetails
+	TransactionDetails AmendCashTransferRequestTransactionDetails
+}
+
+// Validate validate the request before it is sent
+func (m AmendCashTransferRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Authentication),
+		validation.Field(&m.BeneficiaryDetails),
+		validation.Field(&m.TransactionDetails),
+	)
+}
+
+// AmendCashTransferResponse represents FIRe amendment of cash transfer response message
+type AmendCashTransferResponse struct {
+	Error
+	TransactionDetails FireTransactionDetails
+	StatusTransaction  string
+	StatusMessage      string
+}
+
+// CancelCashTransferRequestTransactionDetails identifies cancelled cash transfer
+type CancelCashTransferRequestTransactionDetails struct {
+	ReferenceNumber string
+	FormNumber      string
+	Reason          string
+}
+
+// Validate validate the transaction details before it is sent
+func (m CancelCashTransferRequestTransactionDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.ReferenceNumber, validation.Required),
+		validation.Field(&m.FormNumber, validation.Required, validation.Length(1, 16)),
+		validation.Field(&m.Reason, validation.RuneLength(0, 35)),
+	)
+}
+
+// CancelCashTransferRequest represents FIRe cancellation of unpaid cash transfer request message
+type CancelCashTransferRequest struct {
+	Authentication     Authentication
+	TransactionDetails CancelCashTransferRequestTransactionDetails
+}
+
+// Validate validate the request before it is sent
+func (m CancelCashTransferRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Authentication),
+		validation.Field(&m.TransactionDetails),
+	)
+}
+
+// CancelCashTransferResponse represents FIRe cancellation of cash transfer response message
+type CancelCashTransferResponse struct {
+	Error
+	TransactionDetails FireTransactionDetails
+	StatusTransaction  string
+	StatusMessage      string
+}
+
+// Represent InquiryBy of FIRe inquiry transaction request
+const (
+	FireInquiryByReferenceNumber = "R"
+	FireInquiryByFormNumber      = "F"
+)
+
+// InquiryTransactionRequestTransactionDetails identifies inquired FIRe transaction
+type InquiryTransactionRequestTransactionDetails struct {
+	InquiryBy    string // FireInquiryByReferenceNumber or FireInquiryByFormNumber
+	InquiryValue string // ReferenceNumber or FormNumber of the transaction
+}
+
+// Validate validate the transaction details before it is sent
+func (m InquiryTransactionRequestTransactionDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.InquiryBy, validation.Required, validation.In(FireInquiryByReferenceNumber, FireInquiryByFormNumber)),
+		validation.Field(&m.InquiryValue, validation.Required, validation.Length(1, 16)),
+	)
+}
+
+// InquiryTransactionRequest represents FIRe inquiry transaction request message
+type InquiryTransactionRequest struct {
+	Authentication     Authentication
+	TransactionDetails InquiryTransactionRequestTransactionDetails
+}
+
+// Validate validate the request before it is sent
+func (m InquiryTransactionRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Authentication),
+		validation.Field(&m.TransactionDetails),
+	)
+}
+
+// InquiryTransactionResponseSenderDetails is sender details of FIRe inquiry transaction response
+type InquiryTransactionResponseSenderDetails struct {
+	FirstName string
+	LastName  string
+}
+
+// InquiryTransactionResponseBeneficiaryDetails is beneficiary details of FIRe inquiry transaction response,
+// AccountNumber is empty for cash transfer
+type InquiryTransactionResponseBeneficiaryDetails struct {
+	Name          string
+	AccountNumber string
+}
+
+// InquiryTransactionResponse represents FIRe inquiry transaction response message,
+// StatusTransaction is status of the inquired transaction
+type InquiryTransactionResponse struct {
+	Error
+	SenderDetails      InquiryTransactionResponseSenderDetails
+	BeneficiaryDetails InquiryTransactionResponseBeneficiaryDetails
+	TransactionDetails FireTransactionDetails
+	StatusTransaction  string
+	StatusMessage      string
+}
+
+// InquiryBalanceRequestFIDetails identifies inquired FIRe prefunded account
+type InquiryBalanceRequestFIDetails struct {
+	CurrencyID    string
+	AccountNumber string
+}
+
+// Validate validate the FI details before it is sent
+func (m InquiryBalanceRequestFIDetails) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.CurrencyID, validation.Required, validation.Length(3, 3)),
+		validation.Field(&m.AccountNumber, validation.Required, validation.Length(1, 34)),
+	)
+}
+
+// InquiryBalanceRequest represents FIRe inquiry balance request message
+type InquiryBalanceRequest struct {
+	Authentication Authentication
+	FIDetails      InquiryBalanceRequestFIDetails
+}
+
+// Validate validate the request before it is sent
+func (m InquiryBalanceRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Authentication),
+		validation.Field(&m.FIDetails),
+	)
+}
+
+// InquiryBalanceResponseFIDetails is FI details of FIRe inquiry balance response
+type InquiryBalanceResponseFIDetails struct {
+	CurrencyID     string
+	AccountBalance Amount
+}
+
+// InquiryBalanceResponse represents FIRe inquiry balance response message
+type InquiryBalanceResponse struct {
+	Error
+	FIDetails         InquiryBalanceResponseFIDetails
+	StatusTransaction string
+	StatusMessage     string
+}
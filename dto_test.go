@@ -0,0 +1,119 @@
+package bca_test
+
+import (
+	"sort"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/purwaren/bca-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestValidate(t *testing.T) {
+	transfer := func(modify func(*bca.FundTransferRequest)) bca.FundTransferRequest {
+		dtoReq := bca.FundTransferRequest{
+			CorporateID:              "BCAAPI2016",
+			SourceAccountNumber:      "0201245680",
+			TransactionID:            "00000001",
+			TransactionDate:          "2016-01-30",
+			ReferenceID:              "12345/PO/2016",
+			CurrencyCode:             "IDR",
+			Amount:                   bca.NewAmount(100000, 0),
+			BeneficiaryAccountNumber: "0201245681",
+			Remark1:                  "Transfer Test",
+		}
+		modify(&dtoReq)
+		return dtoReq
+	}
+	domestic := func(modify func(*bca.FundTransferDomesticRequest)) bca.FundTransferDomesticRequest {
+		dtoReq := bca.FundTransferDomesticRequest{
+			TransactionID:            "00000001",
+			TransactionDate:          "2018-05-03",
+			ReferenceID:              "12345/PO/2016",
+			SourceAccountNumber:      "0201245680",
+			BeneficiaryAccountNumber: "0201245501",
+			BeneficiaryBankCode:      "BRONINJA",
+			BeneficiaryName:          "Tester",
+			Amount:                   bca.NewAmount(100000, 0),
+			TransferType:             bca.TransferTypeLLG,
+			BeneficiaryCustType:      "1",
+			BeneficiaryCustResidence: "1",
+			CurrencyCode:             "IDR",
+		}
+		modify(&dtoReq)
+		return dtoReq
+	}
+	inquiry := func(modify func(*bca.InquiryAccountRequest)) bca.InquiryAccountRequest {
+		dtoReq := bca.InquiryAccountRequest{
+			Authentication: bca.Authentication{CorporateID: "CORPID", AccessCode: "ACCESS", BranchCode: "BRANCH", UserID: "USER", LocalID: "LOCAL"},
+			BeneficiaryDetails: bca.InquiryAccountRequestBeneficiaryDetails{
+				BankCodeType:  "BIC",
+				BankCodeValue: "CENAIDJAXXX",
+				AccountNumber: "0106666011",
+			},
+		}
+		modify(&dtoReq)
+		return dtoReq
+	}
+
+	tests := []struct {
+		name       string
+		dtoReq     validation.Validatable
+		wantFields []string
+	}{
+		{"balance", bca.BalanceInfoRequest{AccountNumber: "0201245680,0063001004"}, nil},
+		{"balance empty", bca.BalanceInfoRequest{AccountNumber: " , "}, []string{"AccountNumbers"}},
+		{"balance invalid account", bca.BalanceInfoRequest{AccountNumbers: []string{"0201245680", "12345"}}, []string{"AccountNumbers.1"}},
+		{"statement", bca.AccountStatementRequest{AccountNumber: "0201245680", StartDate: "2016-08-29", EndDate: "2016-09-01"}, nil},
+		{"statement invalid date", bca.AccountStatementRequest{AccountNumber: "0201245680", StartDate: "29/08/2016"}, []string{"EndDate", "StartDate"}},
+		{"transfer", transfer(func(*bca.FundTransferRequest) {}), nil},
+		{"transfer empty", bca.FundTransferRequest{}, []string{
+			"Amount", "BeneficiaryAccountNumber", "CorporateID", "CurrencyCode", "ReferenceID", "SourceAccountNumber", "TransactionDate", "TransactionID",
+		}},
+		{"transfer negative amount", transfer(func(r *bca.FundTransferRequest) { r.Amount = bca.NewAmount(-1, 0) }), []string{"Amount"}},
+		{"transfer too large amount", transfer(func(r *bca.FundTransferRequest) { r.Amount = bca.NewAmount(10000000000000, 0) }), []string{"Amount"}},
+		{"transfer unsupported currency", transfer(func(r *bca.FundTransferRequest) { r.CurrencyCode = "USD" }), []string{"CurrencyCode"}},
+		{"transfer invalid TransactionID", transfer(func(r *bca.FundTransferRequest) { r.TransactionID = "1" }), []string{"TransactionID"}},
+		{"transfer too long remark", transfer(func(r *bca.FundTransferRequest) { r.Remark2 = "Remark is too long for BCA" }), []string{"Remark2"}},
+		{"domestic", domestic(func(*bca.FundTransferDomesticRequest) {}), nil},
+		{"domestic invalid TransferType", domestic(func(r *bca.FundTransferDomesticRequest) { r.TransferType = "SWIFT" }), []string{"TransferType"}},
+		{"domestic LLG without customer", domestic(func(r *bca.FundTransferDomesticRequest) {
+			r.BeneficiaryCustType, r.BeneficiaryCustResidence = "", ""
+		}), []string{"BeneficiaryCustResidence", "BeneficiaryCustType"}},
+		{"domestic ONL without customer", domestic(func(r *bca.FundTransferDomesticRequest) {
+			r.TransferType, r.BeneficiaryCustType, r.BeneficiaryCustResidence = bca.TransferTypeONL, "", ""
+		}), nil},
+		{"domestic invalid customer", domestic(func(r *bca.FundTransferDomesticRequest) { r.BeneficiaryCustType = "4" }), []string{"BeneficiaryCustType"}},
+		{"domestic too long name", domestic(func(r *bca.FundTransferDomesticRequest) { r.BeneficiaryName = "Beneficiary Name Too Long" }), []string{"BeneficiaryName"}},
+		{"transfer status", bca.TransferStatusRequest{TransactionID: "00000001", TransactionDate: "2016-01-30", TransferType: bca.TransferTypeBCA}, nil},
+		{"transfer status invalid TransferType", bca.TransferStatusRequest{TransactionID: "00000001", TransactionDate: "2016-01-30", TransferType: "XXX"}, []string{"TransferType"}},
+		{"inquiry account", inquiry(func(*bca.InquiryAccountRequest) {}), nil},
+		{"inquiry account missing", inquiry(func(r *bca.InquiryAccountRequest) {
+			r.Authentication.AccessCode, r.BeneficiaryDetails.AccountNumber = "", ""
+		}), []string{"Authentication.AccessCode", "BeneficiaryDetails.AccountNumber"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dtoReq.Validate()
+			if tt.wantFields == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var fields []string
+			collectFields(&fields, "", err.(validation.Errors))
+			sort.Strings(fields)
+			require.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+func collectFields(fields *[]string, prefix string, errs validation.Errors) {
+	for field, err := range errs {
+		if nested, ok := err.(validation.Errors); ok {
+			collectFields(fields, prefix+field+".", nested)
+			continue
+		}
+		*fields = append(*fields, prefix+field)
+	}
+}
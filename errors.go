@@ -0,0 +1,355 @@
+package bca
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/juju/errors"
+	bcaCtx "github.com/purwaren/bca-api/context"
+)
+
+// Known error codes of BCA API
+const (
+	ErrCodeHMACMismatch         = "ESB-14-001" // HMAC tidak cocok / HMAC mismatch
+	ErrCodeInvalidRequest       = "ESB-14-002" // Permintaan tidak valid / Invalid request
+	ErrCodeInvalidTimestamp     = "ESB-14-003" // Timestamp tidak valid / Invalid timestamp
+	ErrCodeMissingParameter     = "ESB-14-004" // Parameter harus diisi / Parameter must not be empty
+	ErrCodeOriginNotAllowed     = "ESB-14-005" // Origin tidak diizinkan / Origin not allowed
+	ErrCodeInvalidCorporateID   = "ESB-14-006" // CorporateID tidak valid / Invalid CorporateID
+	ErrCodeInvalidParameter     = "ESB-14-007" // Parameter tidak valid / Invalid parameter
+	ErrCodeInvalidClient        = "ESB-14-008" // client_id/client_secret/grant_type tidak valid / Invalid client_id/client_secret/grant_type
+	ErrCodeUnauthorized         = "ESB-14-009" // Tidak berhak / Unauthorized
+	ErrCodeServiceNotFound      = "ESB-14-011" // Service tidak ada / Service doesn't exist
+	ErrCodeInvalidContentType   = "ESB-14-015" // Content Type tidak valid / Invalid Content Type
+	ErrCodeInvalidJSON          = "ESB-14-016" // Format JSON tidak valid / Invalid JSON format
+	ErrCodeConnectionNotAllowed = "ESB-14-019" // Koneksi tidak diizinkan / Connection not allowed
+	ErrCodeInvalidAPIKey        = "ESB-14-021" // API Key tidak valid / Invalid API Key
+	ErrCodeMandatoryField       = "ESB-82-001" // Field harus diisi / Mandatory field
+	ErrCodeInvalidFieldFormat   = "ESB-82-002" // Format field tidak valid / Invalid field format
+	ErrCodeInvalidAccount       = "ESB-82-005" // Rekening tidak valid / Invalid account
+	ErrCodeInsufficientFunds    = "ESB-82-006" // Saldo tidak cukup / Insufficient funds
+	ErrCodeDuplicateTransaction = "ESB-82-019" // TransactionID sudah digunakan / Duplicate TransactionID
+	ErrCodeTransactionNotFound  = "ESB-82-023" // Transaksi tidak ditemukan / Transaction not found
+	ErrCodeSystemUnavailable    = "ESB-99-999" // Sistem sedang tidak tersedia / System unavailable
+)
+
+// ErrTransferFailed is returned when transfer status inquiry resolving ambiguous transfer reports it as failed
+var ErrTransferFailed = errors.New("transfer failed")
+
+type errorCodeKind int
+
+const (
+	errorCodeAuth errorCodeKind = 1 << iota
+	errorCodeInsufficientFunds
+	errorCodeDuplicateTransaction
+	errorCodeRetryable
+	errorCodeTransactionNotFound
+)
+
+// knownErrorCodes is catalogue of BCA API error codes which are classified by Is* helpers
+var knownErrorCodes = map[string]errorCodeKind{
+	ErrCodeHMACMismatch:         errorCodeAuth,
+	ErrCodeInvalidClient:        errorCodeAuth,
+	ErrCodeUnauthorized:         errorCodeAuth,
+	ErrCodeInvalidAPIKey:        errorCodeAuth,
+	ErrCodeInsufficientFunds:    errorCodeInsufficientFunds,
+	ErrCodeDuplicateTransaction: errorCodeDuplicateTransaction,
+	ErrCodeTransactionNotFound:  errorCodeTransactionNotFound,
+	ErrCodeInvalidTimestamp:     errorCodeRetryable,
+	ErrCodeSystemUnavailable:    errorCodeRetryable,
+}
+
+// ErrorClass classifies errors returned by BCA methods
+type ErrorClass int
+
+// Represent error classes
+const (
+	ErrorClassUnknown             ErrorClass = iota
+	ErrorClassTransport                      // request or response is not completely transferred, e.g. timeout (*TransportError)
+	ErrorClassGateway                        // non-2xx response which is not BCA error response, e.g. 502 HTML page (*GatewayError)
+	ErrorClassAPI                            // BCA error response (*APIError)
+	ErrorClassDecode                         // 2xx response which can not be decoded (*DecodeError)
+	ErrorClassValidation                     // request is invalid and not sent (*ValidationError)
+	ErrorClassBeneficiaryMismatch            // domestic transfer is not sent, beneficiary account name does not match (*BeneficiaryMismatchError)
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassTransport:
+		return "transport"
+	case ErrorClassGateway:
+		return "gateway"
+	case ErrorClassAPI:
+		return "api"
+	case ErrorClassDecode:
+		return "decode"
+	case ErrorClassValidation:
+		return "validation"
+	case ErrorClassBeneficiaryMismatch:
+		return "beneficiary mismatch"
+	}
+	return "unknown"
+}
+
+// ClassOf return class of err
+func ClassOf(err error) ErrorClass {
+	class := ErrorClassUnknown
+	findError(err, func(err error) bool {
+		switch err.(type) {
+		case *TransportError:
+			class = ErrorClassTransport
+		case *GatewayError:
+			class = ErrorClassGateway
+		case *APIError:
+			class = ErrorClassAPI
+		case *DecodeError:
+			class = ErrorClassDecode
+		case *ValidationError:
+			class = ErrorClassValidation
+		case *BeneficiaryMismatchError:
+			class = ErrorClassBeneficiaryMismatch
+		default:
+			return false
+		}
+		return true
+	})
+	return class
+}
+
+// TransportError represents failure to send request or to receive response
+type TransportError struct {
+	Endpoint string // e.g. "POST /banking/corporates/transfers"
+	Err      error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("BCA API transport error (%s): %s", e.Endpoint, e.Err)
+}
+
+// Unwrap return the underlying error
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout report whether the underlying error is a timeout
+func (e *TransportError) Timeout() bool {
+	timeout, ok := e.Err.(interface{ Timeout() bool })
+	return ok && timeout.Timeout()
+}
+
+// GatewayError represents non-2xx response which is not BCA error response
+type GatewayError struct {
+	HTTPStatus int
+	Header     http.Header
+	Body       []byte
+	Endpoint   string
+}
+
+func (e *GatewayError) Error() string {
+	return fmt.Sprintf("BCA API gateway error (HTTP %d %s): %s", e.HTTPStatus, e.Endpoint, abbreviate(e.Body))
+}
+
+// APIError represents error response of BCA API, it is returned whenever ErrorCode is not empty
+type APIError struct {
+	HTTPStatus   int
+	ErrorCode    string
+	ErrorMessage ErrorLang
+	RequestID    string // httpReqID of the context
+	Endpoint     string // e.g. "POST /banking/corporates/transfers"
+	Header       http.Header
+	Body         []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("BCA API error %s (HTTP %d %s): %s", e.ErrorCode, e.HTTPStatus, e.Endpoint, e.ErrorMessage.English)
+}
+
+// DecodeError represents 2xx response which can not be decoded
+type DecodeError struct {
+	HTTPStatus int
+	Header     http.Header
+	Body       []byte
+	Endpoint   string
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("BCA API decode error (HTTP %d %s): %s: %s", e.HTTPStatus, e.Endpoint, e.Err, abbreviate(e.Body))
+}
+
+// Unwrap return the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// AmbiguousTransferError represents transfer failure of which BCA may have booked the transfer,
+// e.g. timeout and transfer status inquiry can not resolve it
+type AmbiguousTransferError struct {
+	TransactionID   string
+	TransactionDate string
+	Err             error // error of the transfer
+	StatusErr       error // error of the transfer status inquiry
+}
+
+func (e *AmbiguousTransferError) Error() string {
+	return fmt.Sprintf("outcome of transfer %s on %s is unknown: %s (status inquiry: %s)", e.TransactionID, e.TransactionDate, e.Err, e.StatusErr)
+}
+
+// Unwrap return error of the transfer
+func (e *AmbiguousTransferError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError represents invalid request, it is returned before the request is sent to BCA
+type ValidationError struct {
+	Request string            // e.g. "FundTransferRequest"
+	Fields  map[string]string // invalid fields, nested fields are dot separated (e.g. "Authentication.CorporateID"), to their errors
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, msg := range e.Fields {
+		fields = append(fields, field+": "+msg)
+	}
+	sort.Strings(fields)
+	return fmt.Sprintf("invalid %s: %s", e.Request, strings.Join(fields, "; "))
+}
+
+// BeneficiaryMismatchError represents domestic transfer aborted before it is sent,
+// BeneficiaryName does not match the beneficiary account name inquired from BCA
+type BeneficiaryMismatchError struct {
+	BeneficiaryBankCode      string
+	BeneficiaryAccountNumber string
+	BeneficiaryName          string  // name given in the transfer request
+	AccountName              string  // name of the beneficiary account
+	Similarity               float64 // similarity of the names, from 0 to 1
+	Threshold                float64
+}
+
+func (e *BeneficiaryMismatchError) Error() string {
+	return fmt.Sprintf("beneficiary name %q does not match name %q of account %s at %s (similarity %.2f < %.2f)",
+		e.BeneficiaryName, e.AccountName, e.BeneficiaryAccountNumber, e.BeneficiaryBankCode, e.Similarity, e.Threshold)
+}
+
+// validateRequest validate dtoReq, return *ValidationError if it is invalid
+func validateRequest(dtoReq validation.Validatable) error {
+	err := dtoReq.Validate()
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(validation.Errors)
+	if !ok {
+		return errors.Trace(err)
+	}
+	validationErr := &ValidationError{Request: reflect.TypeOf(dtoReq).Name(), Fields: map[string]string{}}
+	flattenValidationErrors(validationErr.Fields, "", errs)
+	return validationErr
+}
+
+func flattenValidationErrors(fields map[string]string, prefix string, errs validation.Errors) {
+	for field, err := range errs {
+		if nested, ok := err.(validation.Errors); ok {
+			flattenValidationErrors(fields, prefix+field+".", nested)
+			continue
+		}
+		fields[prefix+field] = err.Error()
+	}
+}
+
+func newAPIError(ctx context.Context, resp *http.Response, body []byte, endpoint string, dtoErr Error) *APIError {
+	requestID, _ := ctx.Value(bcaCtx.HTTPReqIDKey).(string)
+	return &APIError{
+		HTTPStatus:   resp.StatusCode,
+		ErrorCode:    dtoErr.ErrorCode,
+		ErrorMessage: dtoErr.ErrorMessage,
+		RequestID:    requestID,
+		Endpoint:     endpoint,
+		Header:       resp.Header,
+		Body:         body,
+	}
+}
+
+func abbreviate(body []byte) string {
+	const maxLen = 128
+	if len(body) > maxLen {
+		return string(body[:maxLen]) + "..."
+	}
+	return string(body)
+}
+
+func (e *APIError) is(kind errorCodeKind) bool {
+	return knownErrorCodes[e.ErrorCode]&kind != 0
+}
+
+// AsAPIError find *APIError in err chain
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	found := findError(err, func(err error) bool {
+		apiErr, _ = err.(*APIError)
+		return apiErr != nil
+	})
+	return apiErr, found
+}
+
+// findError walk err chain, unwrapping both juju errors and Unwrap method, until match return true
+func findError(err error, match func(err error) bool) bool {
+	for err != nil {
+		if match(err) {
+			return true
+		}
+		if cause := errors.Cause(err); cause != err {
+			err = cause
+			continue
+		}
+		unwrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = unwrapper.Unwrap()
+	}
+	return false
+}
+
+// IsAuthError report whether err is caused by invalid credential, signature or access token
+func IsAuthError(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.is(errorCodeAuth)
+}
+
+// IsInsufficientFunds report whether err is caused by insufficient balance of source account
+func IsInsufficientFunds(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.is(errorCodeInsufficientFunds)
+}
+
+// IsDuplicateTransaction report whether err is caused by reused TransactionID, either rejected by BCA or by TransferLedger
+func IsDuplicateTransaction(err error) bool {
+	if errors.Cause(err) == ErrDuplicateTransactionID {
+		return true
+	}
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.is(errorCodeDuplicateTransaction)
+}
+
+// IsTransferFailed report whether err is caused by transfer which BCA reports as failed
+func IsTransferFailed(err error) bool {
+	return errors.Cause(err) == ErrTransferFailed
+}
+
+// IsTransactionNotFound report whether err is caused by transaction which is unknown to BCA
+func IsTransactionNotFound(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.is(errorCodeTransactionNotFound)
+}
+
+// IsRetryable report whether the same request may succeed when it is sent again
+func IsRetryable(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && (apiErr.is(errorCodeRetryable) || apiErr.ErrorCode == ErrCodeUnauthorized)
+}
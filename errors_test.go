@@ -0,0 +1,129 @@
+package bca_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/purwaren/bca-api"
+	bcaCtx "github.com/purwaren/bca-api/context"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError(t *testing.T) {
+	var tokenCount int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth/token" {
+			n := atomic.AddInt32(&tokenCount, 1)
+			_ = json.NewEncoder(w).Encode(bca.AuthToken{AccessToken: fmt.Sprintf("token-%d", n), ExpiresIn: 3600})
+			return
+		}
+
+		switch r.Header.Get("Authorization") {
+		case "Bearer token-1":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ErrorCode":"ESB-14-009","ErrorMessage":{"Indonesian":"Tidak berhak","English":"Unauthorized"}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"ErrorCode":"ESB-82-006","ErrorMessage":{"Indonesian":"Saldo tidak cukup","English":"Insufficient funds"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	b := bca.New(bca.Config{URL: srv.URL, CorporateID: "BCAAPI2016"})
+
+	ctx := bcaCtx.With(context.Background(), bcaCtx.HTTPReqID("QGSB9jjVifVUie9NznfMwW"))
+	_, err := b.BankingFundTransfer(ctx, bca.FundTransferRequest{
+		SourceAccountNumber:      "0201245680",
+		TransactionID:            "00000001",
+		TransactionDate:          "2016-01-30",
+		ReferenceID:              "12345/PO/2016",
+		CurrencyCode:             "IDR",
+		Amount:                   bca.NewAmount(100000, 0),
+		BeneficiaryAccountNumber: "0201245681",
+	})
+	require.Error(t, err)
+
+	// refused token-1 is replaced by token-2 on retry
+	require.EqualValues(t, 2, atomic.LoadInt32(&tokenCount))
+
+	apiErr, ok := bca.AsAPIError(err)
+	require.True(t, ok)
+	require.Contains(t, string(apiErr.Body), "ESB-82-006")
+	apiErr.Header, apiErr.Body = nil, nil
+	require.Equal(t, &bca.APIError{
+		HTTPStatus:   http.StatusBadRequest,
+		ErrorCode:    bca.ErrCodeInsufficientFunds,
+		ErrorMessage: bca.ErrorLang{Indonesian: "Saldo tidak cukup", English: "Insufficient funds"},
+		RequestID:    "QGSB9jjVifVUie9NznfMwW",
+		Endpoint:     "POST /banking/corporates/transfers",
+	}, apiErr)
+
+	require.True(t, bca.IsInsufficientFunds(err))
+	require.False(t, bca.IsAuthError(err))
+	require.False(t, bca.IsRetryable(err))
+}
+
+func TestIsError(t *testing.T) {
+	tests := []struct {
+		name                     string
+		err                      error
+		wantAuth                 bool
+		wantInsufficientFunds    bool
+		wantDuplicateTransaction bool
+		wantRetryable            bool
+	}{
+		{name: "unauthorized", err: &bca.APIError{ErrorCode: bca.ErrCodeUnauthorized}, wantAuth: true, wantRetryable: true},
+		{name: "invalid client", err: errors.Trace(&bca.APIError{ErrorCode: bca.ErrCodeInvalidClient}), wantAuth: true},
+		{name: "insufficient funds", err: errors.Annotate(&bca.APIError{ErrorCode: bca.ErrCodeInsufficientFunds}, "transfer"), wantInsufficientFunds: true},
+		{name: "duplicate transaction", err: fmt.Errorf("transfer: %w", &bca.APIError{ErrorCode: bca.ErrCodeDuplicateTransaction}), wantDuplicateTransaction: true},
+		{name: "system unavailable", err: &bca.APIError{ErrorCode: bca.ErrCodeSystemUnavailable}, wantRetryable: true},
+		{name: "unknown code", err: &bca.APIError{ErrorCode: "ESB-00-000"}},
+		{name: "not api error", err: errors.New("foo")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wantAuth, bca.IsAuthError(tt.err))
+			require.Equal(t, tt.wantInsufficientFunds, bca.IsInsufficientFunds(tt.err))
+			require.Equal(t, tt.wantDuplicateTransaction, bca.IsDuplicateTransaction(tt.err))
+			require.Equal(t, tt.wantRetryable, bca.IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	var callCount int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&callCount, 1)
+	}))
+	defer srv.Close()
+
+	b := bca.New(bca.Config{URL: srv.URL, CorporateID: "BCAAPI2016"})
+
+	_, err := b.FireInquiryAccount(context.Background(), bca.InquiryAccountRequest{
+		BeneficiaryDetails: bca.InquiryAccountRequestBeneficiaryDetails{BankCodeType: "BIC", BankCodeValue: "CENAIDJAXXX"},
+	})
+	require.Equal(t, bca.ErrorClassValidation, bca.ClassOf(err))
+	require.EqualValues(t, 0, atomic.LoadInt32(&callCount))
+
+	validationErr, ok := errors.Cause(err).(*bca.ValidationError)
+	require.True(t, ok)
+	require.Equal(t, "InquiryAccountRequest", validationErr.Request)
+	require.Equal(t, map[string]string{
+		"Authentication.AccessCode":        "cannot be blank",
+		"Authentication.BranchCode":        "cannot be blank",
+		"Authentication.CorporateID":       "cannot be blank",
+		"Authentication.LocalID":           "cannot be blank",
+		"Authentication.UserID":            "cannot be blank",
+		"BeneficiaryDetails.AccountNumber": "cannot be blank",
+	}, validationErr.Fields)
+	require.Equal(t, "invalid InquiryAccountRequest: Authentication.AccessCode: cannot be blank; Authentication.BranchCode: cannot be blank; "+
+		"Authentication.CorporateID: cannot be blank; Authentication.LocalID: cannot be blank; Authentication.UserID: cannot be blank; "+
+		"BeneficiaryDetails.AccountNumber: cannot be blank", validationErr.Error())
+	require.False(t, bca.IsRetryable(err))
+}
@@ -0,0 +1,77 @@
+package bca
+
+// FireStatus is status of FIRe request, mapped from StatusTransaction of FIRe response
+type FireStatus int
+
+// Represent FIRe statuses
+const (
+	FireStatusUnknown FireStatus = iota // response has no StatusTransaction
+	FireStatusSuccess                   // request succeeded
+	FireStatusFailed                    // request failed, StatusMessage describes the failure
+)
+
+// Represent StatusTransaction and StatusMessage of successful FIRe request,
+// based on FIRe section in https://developer.bca.co.id/documentation/
+const (
+	FireStatusTransactionSuccess = "0000"
+	FireStatusMessageSuccess     = "Success"
+)
+
+// ParseFireStatus return FireStatus of statusTransaction.
+// BCA documents only FireStatusTransactionSuccess, every other StatusTransaction is failure.
+func ParseFireStatus(statusTransaction string) FireStatus {
+	switch statusTransaction {
+	case "":
+		return FireStatusUnknown
+	case FireStatusTransactionSuccess:
+		return FireStatusSuccess
+	default:
+		return FireStatusFailed
+	}
+}
+
+func (s FireStatus) String() string {
+	switch s {
+	case FireStatusSuccess:
+		return "Success"
+	case FireStatusFailed:
+		return "Failed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Status return FireStatus of the response
+func (m InquiryAccountResponse) Status() FireStatus {
+	return ParseFireStatus(m.StatusTransaction)
+}
+
+// Status return FireStatus of the response
+func (m TransferToAccountResponse) Status() FireStatus {
+	return ParseFireStatus(m.StatusTransaction)
+}
+
+// Status return FireStatus of the response
+func (m CashTransferResponse) Status() FireStatus {
+	return ParseFireStatus(m.StatusTransaction)
+}
+
+// Status return FireStatus of the response
+func (m AmendCashTransferResponse) Status() FireStatus {
+	return ParseFireStatus(m.StatusTransaction)
+}
+
+// Status return FireStatus of the response
+func (m CancelCashTransferResponse) Status() FireStatus {
+	return ParseFireStatus(m.StatusTransaction)
+}
+
+// Status return FireStatus of the inquiry
+func (m InquiryTransactionResponse) Status() FireStatus {
+	return ParseFireStatus(m.StatusTransaction)
+}
+
+// Status return FireStatus of the response
+func (m InquiryBalanceResponse) Status() FireStatus {
+	return ParseFireStatus(m.StatusTransaction)
+}
@@ -0,0 +1,36 @@
+package bca_test
+
+import (
+	"testing"
+
+	"github.com/purwaren/bca-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFireStatus(t *testing.T) {
+	tests := []struct {
+		statusTransaction string
+		expected          bca.FireStatus
+	}{
+		{"0000", bca.FireStatusSuccess},
+		{"0001", bca.FireStatusFailed},
+		{"9999", bca.FireStatusFailed},
+		{"", bca.FireStatusUnknown},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, bca.ParseFireStatus(tt.statusTransaction), tt.statusTransaction)
+	}
+
+	require.Equal(t, "Success", bca.FireStatusSuccess.String())
+	require.Equal(t, "Failed", bca.FireStatusFailed.String())
+	require.Equal(t, "Unknown", bca.FireStatusUnknown.String())
+}
+
+func TestCashTransferResponse_Status(t *testing.T) {
+	dtoResp := bca.CashTransferResponse{StatusTransaction: "0000", StatusMessage: "Success"}
+	require.Equal(t, bca.FireStatusSuccess, dtoResp.Status())
+
+	// StatusMessage is not matched
+	dtoResp = bca.CashTransferResponse{StatusTransaction: "0001", StatusMessage: "Success"}
+	require.Equal(t, bca.FireStatusFailed, dtoResp.Status())
+}
@@ -0,0 +1,274 @@
+package bca
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// ErrDuplicateTransactionID is returned by TransferLedger when TransactionID is already recorded on the TransactionDate
+var ErrDuplicateTransactionID = errors.New("duplicate TransactionID")
+
+// TransferState represents state of outgoing transfer recorded in TransferLedger
+type TransferState string
+
+// Represent transfer states
+const (
+	TransferStatePending TransferState = "pending" // sent, the result is not known yet
+	TransferStateSuccess TransferState = "success"
+	TransferStateFailed  TransferState = "failed"  // rejected by BCA
+	TransferStateUnknown TransferState = "unknown" // BCA may have booked it, needs reconciliation
+
+	// transferStateReleased marks released entry in FileLedger
+	transferStateReleased TransferState = "released"
+)
+
+// LedgerKey identifies outgoing transfer, BCA requires TransactionID to be unique per corporate per day
+type LedgerKey struct {
+	CorporateID     string
+	TransactionDate string
+	TransactionID   string
+}
+
+// LedgerEntry represents outgoing transfer recorded in TransferLedger
+type LedgerEntry struct {
+	LedgerKey
+	TransferType             string // TransferTypeBCA or TransferType of domestic transfer
+	ReferenceID              string
+	SourceAccountNumber      string
+	BeneficiaryAccountNumber string
+	BeneficiaryBankCode      string `json:",omitempty"`
+	CurrencyCode             string
+	Amount                   Amount
+	State                    TransferState
+	ErrorCode                string `json:",omitempty"`
+	CreatedAt                time.Time
+	UpdatedAt                time.Time
+}
+
+// LedgerQuery filters entries of TransferLedger, zero fields match all entries
+type LedgerQuery struct {
+	CorporateID     string
+	TransactionDate string
+	State           TransferState
+}
+
+func (q LedgerQuery) match(entry LedgerEntry) bool {
+	return (q.CorporateID == "" || q.CorporateID == entry.CorporateID) &&
+		(q.TransactionDate == "" || q.TransactionDate == entry.TransactionDate) &&
+		(q.State == "" || q.State == entry.State)
+}
+
+// TransferLedger records outgoing transfers to reject reused TransactionID before it is sent to BCA
+type TransferLedger interface {
+	// Begin record entry as pending, return ErrDuplicateTransactionID if its key is already recorded
+	Begin(ctx context.Context, entry LedgerEntry) error
+	// Finish update state of recorded entry
+	Finish(ctx context.Context, key LedgerKey, state TransferState, errorCode string) error
+	// Release remove recorded entry of transfer which BCA has not booked, so its TransactionID can be used again
+	Release(ctx context.Context, key LedgerKey) error
+	// Get return recorded entry, nil if there is none
+	Get(ctx context.Context, key LedgerKey) (*LedgerEntry, error)
+	// Query return recorded entries matching query ordered by CreatedAt
+	Query(ctx context.Context, query LedgerQuery) ([]LedgerEntry, error)
+}
+
+// MemoryLedger is TransferLedger kept in process memory
+type MemoryLedger struct {
+	mutex   sync.RWMutex
+	entries map[LedgerKey]LedgerEntry
+}
+
+// NewMemoryLedger return new instance of MemoryLedger
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{entries: map[LedgerKey]LedgerEntry{}}
+}
+
+// Begin record entry as pending, return ErrDuplicateTransactionID if its key is already recorded
+func (l *MemoryLedger) Begin(ctx context.Context, entry LedgerEntry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, err := l.beginLocked(entry)
+	return err
+}
+
+func (l *MemoryLedger) beginLocked(entry LedgerEntry) (LedgerEntry, error) {
+	if _, ok := l.entries[entry.LedgerKey]; ok {
+		return entry, errors.Trace(ErrDuplicateTransactionID)
+	}
+
+	now := time.Now()
+	entry.State = TransferStatePending
+	entry.CreatedAt, entry.UpdatedAt = now, now
+	l.entries[entry.LedgerKey] = entry
+	return entry, nil
+}
+
+// Finish update state of recorded entry
+func (l *MemoryLedger) Finish(ctx context.Context, key LedgerKey, state TransferState, errorCode string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, err := l.finishLocked(key, state, errorCode)
+	return err
+}
+
+func (l *MemoryLedger) finishLocked(key LedgerKey, state TransferState, errorCode string) (LedgerEntry, error) {
+	entry, ok := l.entries[key]
+	if !ok {
+		return entry, errors.NotFoundf("transfer %s on %s", key.TransactionID, key.TransactionDate)
+	}
+
+	entry.State = state
+	entry.ErrorCode = errorCode
+	entry.UpdatedAt = time.Now()
+	l.entries[key] = entry
+	return entry, nil
+}
+
+// Release remove recorded entry of transfer which BCA has not booked, so its TransactionID can be used again
+func (l *MemoryLedger) Release(ctx context.Context, key LedgerKey) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.entries, key)
+	return nil
+}
+
+// Get return recorded entry, nil if there is none
+func (l *MemoryLedger) Get(ctx context.Context, key LedgerKey) (*LedgerEntry, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Query return recorded entries matching query ordered by CreatedAt
+func (l *MemoryLedger) Query(ctx context.Context, query LedgerQuery) ([]LedgerEntry, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	var entries []LedgerEntry
+	for _, entry := range l.entries {
+		if query.match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// FileLedger is TransferLedger kept in an append-only JSON lines file, each line is the latest version of an entry.
+// The file is loaded into memory on open and duplicates are checked only against that memory, so FileLedger is
+// single-process only: the file must not be shared between processes (e.g. service replicas), which would accept
+// the same TransactionID. Replicas must share a TransferLedger backed by a database.
+type FileLedger struct {
+	MemoryLedger
+	file *os.File
+}
+
+// NewFileLedger open (or create) ledger file at path
+func NewFileLedger(path string) (*FileLedger, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	l := &FileLedger{MemoryLedger: MemoryLedger{entries: map[LedgerKey]LedgerEntry{}}, file: file}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry LedgerEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			file.Close()
+			return nil, errors.Annotatef(err, "ledger %s", path)
+		}
+		if entry.State == transferStateReleased {
+			delete(l.entries, entry.LedgerKey)
+			continue
+		}
+		l.entries[entry.LedgerKey] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, errors.Trace(err)
+	}
+
+	return l, nil
+}
+
+// Begin record entry as pending, return ErrDuplicateTransactionID if its key is already recorded
+func (l *FileLedger) Begin(ctx context.Context, entry LedgerEntry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry, err := l.beginLocked(entry)
+	if err != nil {
+		return err
+	}
+	if err := l.appendLocked(entry); err != nil {
+		delete(l.entries, entry.LedgerKey)
+		return err
+	}
+	return nil
+}
+
+// Finish update state of recorded entry
+func (l *FileLedger) Finish(ctx context.Context, key LedgerKey, state TransferState, errorCode string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry, err := l.finishLocked(key, state, errorCode)
+	if err != nil {
+		return err
+	}
+	return l.appendLocked(entry)
+}
+
+// Release remove recorded entry of transfer which BCA has not booked, so its TransactionID can be used again
+func (l *FileLedger) Release(ctx context.Context, key LedgerKey) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return nil
+	}
+	entry.State = transferStateReleased
+	entry.UpdatedAt = time.Now()
+	if err := l.appendLocked(entry); err != nil {
+		return err
+	}
+	delete(l.entries, key)
+	return nil
+}
+
+// Close close the ledger file
+func (l *FileLedger) Close() error {
+	return l.file.Close()
+}
+
+func (l *FileLedger) appendLocked(entry LedgerEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(l.file.Sync())
+}
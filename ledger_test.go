@@ -0,0 +1,107 @@
+package bca_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/purwaren/bca-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bca-ledger")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	path := filepath.Join(dir, "ledger.jsonl")
+
+	ledger, err := bca.NewFileLedger(path)
+	require.NoError(t, err)
+
+	givenKey := bca.LedgerKey{CorporateID: "BCAAPI2016", TransactionDate: "2016-01-30", TransactionID: "00000001"}
+	require.NoError(t, ledger.Begin(ctx, bca.LedgerEntry{LedgerKey: givenKey, Amount: bca.NewAmount(100000, 0)}))
+	require.NoError(t, ledger.Begin(ctx, bca.LedgerEntry{LedgerKey: bca.LedgerKey{CorporateID: "BCAAPI2016", TransactionDate: "2016-01-31", TransactionID: "00000001"}}))
+
+	err = ledger.Begin(ctx, bca.LedgerEntry{LedgerKey: givenKey})
+	require.True(t, bca.IsDuplicateTransaction(err))
+
+	require.NoError(t, ledger.Finish(ctx, givenKey, bca.TransferStateUnknown, ""))
+	require.NoError(t, ledger.Close())
+
+	// reopened ledger keeps the latest state
+	ledger, err = bca.NewFileLedger(path)
+	require.NoError(t, err)
+	defer ledger.Close()
+
+	entry, err := ledger.Get(ctx, givenKey)
+	require.NoError(t, err)
+	require.Equal(t, bca.TransferStateUnknown, entry.State)
+	require.Equal(t, bca.NewAmount(100000, 0), entry.Amount)
+
+	entries, err := ledger.Query(ctx, bca.LedgerQuery{State: bca.TransferStatePending})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "2016-01-31", entries[0].TransactionDate)
+
+	err = ledger.Begin(ctx, bca.LedgerEntry{LedgerKey: givenKey})
+	require.True(t, bca.IsDuplicateTransaction(err))
+
+	t.Run("released entry is not reloaded", func(t *testing.T) {
+		releasedKey := entries[0].LedgerKey
+		require.NoError(t, ledger.Release(ctx, releasedKey))
+		require.NoError(t, ledger.Close())
+
+		ledger, err := bca.NewFileLedger(path)
+		require.NoError(t, err)
+		defer ledger.Close()
+
+		entry, err := ledger.Get(ctx, releasedKey)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+		require.NoError(t, ledger.Begin(ctx, bca.LedgerEntry{LedgerKey: releasedKey}))
+	})
+}
+
+func TestBCA_BankingFundTransfer_ledger(t *testing.T) {
+	var postCount int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth/token" {
+			_ = json.NewEncoder(w).Encode(bca.AuthToken{AccessToken: "lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS", ExpiresIn: 3600})
+			return
+		}
+		postCount++
+		_, _ = w.Write([]byte(`{"TransactionID":"00000001","TransactionDate":"2016-01-30","ReferenceID":"12345/PO/2016","Status":"Success"}`))
+	}))
+	defer srv.Close()
+
+	ledger := bca.NewMemoryLedger()
+	b := bca.New(bca.Config{URL: srv.URL, CorporateID: "BCAAPI2016", Ledger: ledger})
+
+	givenDtoReq := bca.FundTransferRequest{
+		SourceAccountNumber:      "0201245680",
+		TransactionID:            "00000001",
+		TransactionDate:          "2016-01-30",
+		ReferenceID:              "12345/PO/2016",
+		CurrencyCode:             "IDR",
+		Amount:                   bca.NewAmount(100000, 0),
+		BeneficiaryAccountNumber: "0201245681",
+	}
+	_, err := b.BankingFundTransfer(context.Background(), givenDtoReq)
+	require.NoError(t, err)
+
+	_, err = b.BankingFundTransfer(context.Background(), givenDtoReq)
+	require.True(t, bca.IsDuplicateTransaction(err))
+	require.Equal(t, 1, postCount)
+
+	entry, err := ledger.Get(context.Background(), bca.LedgerKey{CorporateID: "BCAAPI2016", TransactionDate: "2016-01-30", TransactionID: "00000001"})
+	require.NoError(t, err)
+	require.Equal(t, bca.TransferStateSuccess, entry.State)
+	require.Equal(t, "0201245681", entry.BeneficiaryAccountNumber)
+}
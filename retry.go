@@ -0,0 +1,148 @@
+package bca
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/avast/retry-go"
+)
+
+// RetryPolicy controls retrying failed BCA API operation.
+// Operation refused due to invalid access token is always retried with a new token,
+// other failures are retried only for idempotent operations (e.g. BankingGetBalance, FireInquiryAccount).
+type RetryPolicy struct {
+	// Attempts is maximum number of attempts including the first one
+	Attempts uint
+	// InitialBackoff is delay before the first retry, it is doubled on each retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter is fraction (0..1] of the backoff which is randomized, zero means DefaultRetryPolicy.Jitter
+	Jitter float64
+	// NoJitter disables randomizing the backoff, Jitter is ignored
+	NoJitter bool
+	// MaxElapsedTime stop retrying once it is exceeded since the first attempt
+	MaxElapsedTime time.Duration
+	// RetryOn lists error classes retried for idempotent operations.
+	// Gateway errors are retried only on 5xx and 429, API errors only if IsRetryable.
+	// Nil means DefaultRetryPolicy.RetryOn, use empty slice to retry none.
+	RetryOn []ErrorClass
+}
+
+// DefaultRetryPolicy is used for zero fields of Config.RetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:       3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Jitter:         0.5,
+	MaxElapsedTime: 10 * time.Second,
+	RetryOn:        []ErrorClass{ErrorClassTransport, ErrorClassGateway, ErrorClassAPI},
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Attempts == 0 {
+		p.Attempts = DefaultRetryPolicy.Attempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	if p.NoJitter {
+		p.Jitter = 0
+	} else if p.Jitter <= 0 || p.Jitter > 1 {
+		p.Jitter = DefaultRetryPolicy.Jitter
+	}
+	if p.MaxElapsedTime <= 0 {
+		p.MaxElapsedTime = DefaultRetryPolicy.MaxElapsedTime
+	}
+	if p.RetryOn == nil {
+		p.RetryOn = DefaultRetryPolicy.RetryOn
+	}
+	return p
+}
+
+// backoff return delay before retry n (zero based)
+func (p RetryPolicy) backoff(n uint) time.Duration {
+	delay := p.InitialBackoff
+	for i := uint(0); i < n && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay - time.Duration(rand.Float64()*p.Jitter*float64(delay))
+}
+
+// retryable report whether operation failed with err should be attempted again
+func (p RetryPolicy) retryable(err error, idempotent bool) bool {
+	// refused access token is invalidated, the request is not processed by BCA
+	if apiErr, ok := AsAPIError(err); ok && apiErr.ErrorCode == ErrCodeUnauthorized {
+		return true
+	}
+	if !idempotent {
+		return false
+	}
+
+	class := ClassOf(err)
+	retryOn := false
+	for _, c := range p.RetryOn {
+		retryOn = retryOn || c == class
+	}
+	if !retryOn {
+		return false
+	}
+
+	switch class {
+	case ErrorClassGateway:
+		var gatewayErr *GatewayError
+		findError(err, func(err error) bool {
+			gatewayErr, _ = err.(*GatewayError)
+			return gatewayErr != nil
+		})
+		return gatewayErr.HTTPStatus >= 500 || gatewayErr.HTTPStatus == http.StatusTooManyRequests
+	case ErrorClassAPI:
+		return IsRetryable(err)
+	}
+	return true
+}
+
+func (b *BCA) retryDecision(ctx context.Context, idempotent bool, start time.Time) func(err error) bool {
+	policy := b.config.RetryPolicy.withDefaults()
+	return func(err error) bool {
+		if ctx.Err() != nil || time.Since(start) > policy.MaxElapsedTime {
+			return false
+		}
+		return policy.retryable(err, idempotent)
+	}
+}
+
+func (b *BCA) retryOptions(ctx context.Context, idempotent bool) []retry.Option {
+	policy := b.config.RetryPolicy.withDefaults()
+	return []retry.Option{
+		retry.Attempts(policy.Attempts),
+		retry.LastErrorOnly(true),
+		retry.RetryIf(b.retryDecision(ctx, idempotent, time.Now())),
+		// retry.Do sleeps regardless of ctx, so the backoff is waited here and stops once ctx is done
+		retry.DelayType(func(n uint, _ *retry.Config) time.Duration {
+			sleep(ctx, policy.backoff(n))
+			return 0
+		}),
+		retry.OnRetry(func(n uint, err error) {
+			b.log(ctx).Infof("=== ON RETRY === [Attempts: %d Err: %+v]", n, err)
+		}),
+	}
+}
+
+// sleep wait for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,124 @@
+package bca
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Jitter:         0.5,
+	}.withDefaults()
+
+	for n, wantMax := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		delay := policy.backoff(uint(n))
+		require.True(t, delay <= wantMax, "retry %d: %s", n, delay)
+		require.True(t, delay >= wantMax/2, "retry %d: %s", n, delay)
+	}
+
+	t.Run("no jitter", func(t *testing.T) {
+		policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, NoJitter: true}.withDefaults()
+		require.Equal(t, 100*time.Millisecond, policy.backoff(0))
+		require.Equal(t, 200*time.Millisecond, policy.backoff(1))
+	})
+}
+
+func TestRetryPolicy_retryable(t *testing.T) {
+	policy := DefaultRetryPolicy
+
+	tests := []struct {
+		name           string
+		err            error
+		wantIdempotent bool
+		wantOther      bool
+	}{
+		{name: "refused token", err: &APIError{ErrorCode: ErrCodeUnauthorized}, wantIdempotent: true, wantOther: true},
+		{name: "transport", err: &TransportError{Err: context.DeadlineExceeded}, wantIdempotent: true},
+		{name: "gateway 502", err: &GatewayError{HTTPStatus: http.StatusBadGateway}, wantIdempotent: true},
+		{name: "gateway 429", err: &GatewayError{HTTPStatus: http.StatusTooManyRequests}, wantIdempotent: true},
+		{name: "gateway 404", err: &GatewayError{HTTPStatus: http.StatusNotFound}},
+		{name: "system unavailable", err: &APIError{ErrorCode: ErrCodeSystemUnavailable}, wantIdempotent: true},
+		{name: "insufficient funds", err: &APIError{ErrorCode: ErrCodeInsufficientFunds}},
+		{name: "decode", err: &DecodeError{HTTPStatus: http.StatusOK}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wantIdempotent, policy.retryable(tt.err, true))
+			require.Equal(t, tt.wantOther, policy.retryable(tt.err, false))
+		})
+	}
+
+	t.Run("retry on none", func(t *testing.T) {
+		policy := RetryPolicy{RetryOn: []ErrorClass{}}.withDefaults()
+		require.False(t, policy.retryable(&TransportError{Err: context.DeadlineExceeded}, true))
+	})
+}
+
+func TestBCA_retryPolicy(t *testing.T) {
+	var callCount int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth/token" {
+			_, _ = w.Write([]byte(`{"access_token":"lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS","expires_in":3600}`))
+			return
+		}
+		if atomic.AddInt32(&callCount, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`<html><body>502 Bad Gateway</body></html>`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"AccountDetailDataSuccess":[{"AccountNumber":"0201245680","Balance":"100000.00"}]}`))
+	}))
+	defer srv.Close()
+
+	b := New(Config{URL: srv.URL, CorporateID: "BCAAPI2016", RetryPolicy: RetryPolicy{InitialBackoff: time.Millisecond}})
+
+	t.Run("idempotent is retried", func(t *testing.T) {
+		atomic.StoreInt32(&callCount, 0)
+		_, err := b.BankingGetBalance(context.Background(), BalanceInfoRequest{AccountNumber: "0201245680"})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, atomic.LoadInt32(&callCount))
+	})
+
+	t.Run("transfer is not retried", func(t *testing.T) {
+		atomic.StoreInt32(&callCount, 0)
+		_, err := b.BankingFundTransfer(context.Background(), FundTransferRequest{
+			SourceAccountNumber:      "0201245680",
+			TransactionID:            "00000001",
+			TransactionDate:          "2016-01-30",
+			ReferenceID:              "12345/PO/2016",
+			CurrencyCode:             "IDR",
+			Amount:                   NewAmount(100000, 0),
+			BeneficiaryAccountNumber: "0201245681",
+		})
+		require.Equal(t, ErrorClassGateway, ClassOf(err))
+		require.EqualValues(t, 1, atomic.LoadInt32(&callCount))
+	})
+}
+
+func TestBCA_retryPolicy_cancelledBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth/token" {
+			_, _ = w.Write([]byte(`{"access_token":"lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS","expires_in":3600}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	b := New(Config{URL: srv.URL, CorporateID: "BCAAPI2016", RetryPolicy: RetryPolicy{InitialBackoff: time.Hour, MaxBackoff: time.Hour, MaxElapsedTime: 2 * time.Hour}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := b.BankingGetBalance(ctx, BalanceInfoRequest{AccountNumber: "0201245680"})
+	require.Error(t, err)
+	require.True(t, time.Since(start) < 10*time.Second, "backoff is not stopped by ctx: %s", time.Since(start))
+}
@@ -6,11 +6,24 @@ import (
 	"encoding/hex"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 
 	"github.com/juju/errors"
 )
 
+// DefaultMaxClockSkew is default maximum difference between X-BCA-Timestamp and local time
+const DefaultMaxClockSkew = 5 * time.Minute
+
+// Represent failures of signature verification
+var (
+	ErrSignatureMismatch = errors.New("X-BCA-Signature mismatch")
+	ErrInvalidTimestamp  = errors.New("invalid X-BCA-Timestamp")
+	ErrTimestampSkew     = errors.New("X-BCA-Timestamp is out of allowed clock skew")
+	ErrSignatureReplay   = errors.New("X-BCA-Signature is replayed")
+)
+
 func canonicalize(str string) string {
 	var b strings.Builder
 	b.Grow(len(str))
@@ -34,15 +47,23 @@ func sortQueryParam(urlStr string) (string, error) {
 
 // GenerateSignature generate SHA-256 HMAC signature
 func GenerateSignature(apiSecret, method, path, accessToken, requestBody, timestamp string) (signature string, strToSign string, err error) {
+	mac, strToSign, err := signatureMAC(apiSecret, method, path, accessToken, requestBody, timestamp)
+	if err != nil {
+		return "", strToSign, errors.Trace(err)
+	}
+	return hex.EncodeToString(mac), strToSign, nil
+}
+
+func signatureMAC(apiSecret, method, path, accessToken, requestBody, timestamp string) (mac []byte, strToSign string, err error) {
 	canonicalReqBody := canonicalize(requestBody)
 	h := sha256.New()
 	if _, err := h.Write([]byte(canonicalReqBody)); err != nil {
-		return "", "", errors.Trace(err)
+		return nil, "", errors.Trace(err)
 	}
 
 	sortedURL, err := sortQueryParam(path)
 	if err != nil {
-		return "", "", errors.Trace(err)
+		return nil, "", errors.Trace(err)
 	}
 
 	strToSign = method + ":" +
@@ -51,9 +72,98 @@ func GenerateSignature(apiSecret, method, path, accessToken, requestBody, timest
 		strings.ToLower(hex.EncodeToString(h.Sum(nil))) + ":" +
 		timestamp
 
-	mac := hmac.New(sha256.New, []byte(apiSecret))
-	if _, err = mac.Write([]byte(strToSign)); err != nil {
-		return "", strToSign, errors.Trace(err)
+	m := hmac.New(sha256.New, []byte(apiSecret))
+	if _, err = m.Write([]byte(strToSign)); err != nil {
+		return nil, strToSign, errors.Trace(err)
+	}
+	return m.Sum(nil), strToSign, nil
+}
+
+// ReplayCache remembers verified signatures to reject replayed requests
+type ReplayCache interface {
+	// Seen record signature until expiresAt, report whether it is already recorded
+	Seen(signature string, expiresAt time.Time) (bool, error)
+}
+
+// VerifyOptions controls VerifySignature
+type VerifyOptions struct {
+	// MaxClockSkew is maximum difference between timestamp and Now, zero means DefaultMaxClockSkew
+	MaxClockSkew time.Duration
+	// Now return local time, nil means time.Now
+	Now func() time.Time
+	// ReplayCache rejects signature which is already verified, nil disables replay protection
+	ReplayCache ReplayCache
+}
+
+// VerifySignature verify SHA-256 HMAC signature of request signed at timestamp (X-BCA-Timestamp).
+// Return ErrSignatureMismatch, ErrInvalidTimestamp, ErrTimestampSkew or ErrSignatureReplay as the cause of failure.
+func VerifySignature(apiSecret, method, path, token, body, timestamp, signature string, opts VerifyOptions) error {
+	signedAt, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return errors.Annotate(ErrInvalidTimestamp, err.Error())
+	}
+
+	maxClockSkew := opts.MaxClockSkew
+	if maxClockSkew <= 0 {
+		maxClockSkew = DefaultMaxClockSkew
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	if skew := now().Sub(signedAt); skew > maxClockSkew || skew < -maxClockSkew {
+		return errors.Annotatef(ErrTimestampSkew, "clock skew %s", skew)
+	}
+
+	expectedMAC, _, err := signatureMAC(apiSecret, method, path, token, body, timestamp)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	mac, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, expectedMAC) {
+		return errors.Trace(ErrSignatureMismatch)
+	}
+
+	if opts.ReplayCache != nil {
+		// older signature is rejected by the clock skew check
+		seen, err := opts.ReplayCache.Seen(strings.ToLower(signature), signedAt.Add(maxClockSkew))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if seen {
+			return errors.Trace(ErrSignatureReplay)
+		}
+	}
+	return nil
+}
+
+// MemoryReplayCache is ReplayCache kept in process memory
+type MemoryReplayCache struct {
+	mutex      sync.Mutex
+	signatures map[string]time.Time
+	now        func() time.Time
+}
+
+// NewMemoryReplayCache return new instance of MemoryReplayCache
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{signatures: map[string]time.Time{}, now: time.Now}
+}
+
+// Seen record signature until expiresAt, report whether it is already recorded
+func (c *MemoryReplayCache) Seen(signature string, expiresAt time.Time) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	for s, e := range c.signatures {
+		if !now.Before(e) {
+			delete(c.signatures, s)
+		}
+	}
+
+	if _, ok := c.signatures[signature]; ok {
+		return true, nil
 	}
-	return hex.EncodeToString(mac.Sum(nil)), strToSign, nil
+	c.signatures[signature] = expiresAt
+	return false, nil
 }
@@ -2,8 +2,11 @@ package bca
 
 import (
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/juju/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -157,3 +160,65 @@ func Test_canonicalize(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifySignature(t *testing.T) {
+	const (
+		apiSecret   = "22a2d25e-765d-41e1-8d29-da68dcb5698b"
+		path        = "/banking/v2/corporates/BCAAPI2016/accounts/0201245680/statements?StartDate=2016-09-01&EndDate=2016-09-01"
+		accessToken = "lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS"
+		timestamp   = "2016-02-03T10:00:00.000+07:00"
+		signature   = "3ac124303746d222387d4398dddf33201a384aa22137aa08f4d9843c6f467a48"
+	)
+	signedAt, err := time.Parse(time.RFC3339, timestamp)
+	require.NoError(t, err)
+
+	at := func(d time.Duration) VerifyOptions {
+		return VerifyOptions{Now: func() time.Time { return signedAt.Add(d) }}
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		timestamp string
+		signature string
+		opts      VerifyOptions
+		wantErr   error
+	}{
+		{"valid", path, timestamp, signature, at(time.Minute), nil},
+		{"valid upper case", path, timestamp, strings.ToUpper(signature), at(-time.Minute), nil},
+		{"reordered query", "/banking/v2/corporates/BCAAPI2016/accounts/0201245680/statements?EndDate=2016-09-01&StartDate=2016-09-01", timestamp, signature, at(0), nil},
+		{"mismatch", path + "&x=1", timestamp, signature, at(0), ErrSignatureMismatch},
+		{"not hex", path, timestamp, "signature", at(0), ErrSignatureMismatch},
+		{"invalid timestamp", path, "03/02/2016 10:00:00", signature, at(0), ErrInvalidTimestamp},
+		{"skew", path, timestamp, signature, at(6 * time.Minute), ErrTimestampSkew},
+		{"allowed skew", path, timestamp, signature, VerifyOptions{MaxClockSkew: 10 * time.Minute, Now: at(6 * time.Minute).Now}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(apiSecret, http.MethodGet, tt.path, accessToken, "", tt.timestamp, tt.signature, tt.opts)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.Equal(t, tt.wantErr, errors.Cause(err))
+			}
+		})
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		cache := NewMemoryReplayCache()
+		cache.now = at(0).Now
+		opts := at(0)
+		opts.ReplayCache = cache
+
+		require.NoError(t, VerifySignature(apiSecret, http.MethodGet, path, accessToken, "", timestamp, signature, opts))
+		err := VerifySignature(apiSecret, http.MethodGet, path, accessToken, "", timestamp, strings.ToUpper(signature), opts)
+		require.Equal(t, ErrSignatureReplay, errors.Cause(err))
+
+		// expired signatures are forgotten
+		cache.now = at(DefaultMaxClockSkew).Now
+		seen, err := cache.Seen("other", signedAt.Add(time.Hour))
+		require.NoError(t, err)
+		require.False(t, seen)
+		require.Len(t, cache.signatures, 1)
+	})
+}
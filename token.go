@@ -0,0 +1,237 @@
+package bca
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/lithammer/shortuuid"
+)
+
+// defaultTokenRefreshBefore is how long before its expiry an access token is refreshed
+const defaultTokenRefreshBefore = time.Minute
+
+// tokenFetchTimeout bounds shared token fetch, which does not follow deadline of any caller
+const tokenFetchTimeout = 30 * time.Second
+
+// tokenManager keeps access token in TokenStore and refresh it ahead of its expiry.
+// Concurrent refreshes are collapsed into a single fetch.
+type tokenManager struct {
+	fetch         func(ctx context.Context) (*AuthToken, error)
+	store         TokenStore
+	refreshBefore time.Duration
+	now           func() time.Time
+
+	mutex     sync.Mutex
+	refused   string // access token refused by BCA
+	sessToken string // access token of bcaSessID
+	bcaSessID string
+	inflight  *tokenCall
+}
+
+// tokenCall is an in-flight fetch shared by concurrent callers
+type tokenCall struct {
+	done    chan struct{}
+	forced  bool
+	dtoResp *AuthToken
+	err     error
+}
+
+func newTokenManager(fetch func(ctx context.Context) (*AuthToken, error), store TokenStore, refreshBefore time.Duration) *tokenManager {
+	if store == nil {
+		store = NewMemoryTokenStore()
+	}
+	if refreshBefore <= 0 {
+		refreshBefore = defaultTokenRefreshBefore
+	}
+
+	return &tokenManager{
+		fetch:         fetch,
+		store:         store,
+		refreshBefore: refreshBefore,
+		now:           time.Now,
+	}
+}
+
+// token return current access token, fetching a new one when it is missing or about to expire
+func (m *tokenManager) token(ctx context.Context) (string, error) {
+	token, err := m.store.Get(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	m.mutex.Lock()
+	if m.usableLocked(token) {
+		m.sessLocked(token.AccessToken)
+		m.mutex.Unlock()
+		return token.AccessToken, nil
+	}
+	call := m.startLocked(ctx, false)
+	m.mutex.Unlock()
+
+	dtoResp, err := call.wait(ctx)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if dtoResp.AccessToken == "" {
+		return "", errors.New("Auth err from BCA API (empty access token)")
+	}
+	return dtoResp.AccessToken, nil
+}
+
+// refresh fetch a new access token regardless of the current one.
+// A running fetch that is not forced may return the refused token, so it is awaited and followed by a forced one.
+func (m *tokenManager) refresh(ctx context.Context) (*AuthToken, error) {
+	for {
+		m.mutex.Lock()
+		if running := m.inflight; running != nil && !running.forced {
+			m.mutex.Unlock()
+			select {
+			case <-running.done:
+				continue
+			case <-ctx.Done():
+				return nil, errors.Trace(ctx.Err())
+			}
+		}
+		call := m.startLocked(ctx, true)
+		m.mutex.Unlock()
+
+		return call.wait(ctx)
+	}
+}
+
+// invalidate mark accessToken as refused, so the next token call fetch a new one
+func (m *tokenManager) invalidate(accessToken string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.refused = accessToken
+}
+
+func (m *tokenManager) sessID() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.bcaSessID
+}
+
+// usableLocked report whether token is present, not refused and not about to expire, m.mutex must be held
+func (m *tokenManager) usableLocked(token *Token) bool {
+	if token == nil || token.AccessToken == "" || token.AccessToken == m.refused {
+		return false
+	}
+	if token.ExpiresAt.IsZero() {
+		return true
+	}
+
+	refreshBefore := m.refreshBefore
+	if lifetime := token.ExpiresAt.Sub(token.IssuedAt); refreshBefore > lifetime/2 {
+		refreshBefore = lifetime / 2
+	}
+	return m.now().Before(token.ExpiresAt.Add(-refreshBefore))
+}
+
+// sessLocked start a new bcaSessID whenever access token changes, m.mutex must be held
+func (m *tokenManager) sessLocked(accessToken string) {
+	if m.sessToken != accessToken {
+		m.sessToken = accessToken
+		m.bcaSessID = shortuuid.New()
+	}
+}
+
+// startLocked join the in-flight fetch or start a new one, m.mutex must be held.
+// The fetch is shared, so it runs detached from cancellation of ctx, keeping its values (e.g. httpReqID) for logging.
+func (m *tokenManager) startLocked(ctx context.Context, force bool) *tokenCall {
+	if m.inflight != nil {
+		return m.inflight
+	}
+
+	call := &tokenCall{done: make(chan struct{}), forced: force}
+	m.inflight = call
+
+	go func() {
+		fetchCtx, cancel := context.WithTimeout(detachedContext{ctx}, tokenFetchTimeout)
+		defer cancel()
+		dtoResp, err := m.fetchAndStore(fetchCtx, force)
+
+		m.mutex.Lock()
+		if err == nil && dtoResp.AccessToken != "" {
+			m.sessLocked(dtoResp.AccessToken)
+		}
+		m.inflight = nil
+		m.mutex.Unlock()
+
+		call.dtoResp, call.err = dtoResp, err
+		close(call.done)
+	}()
+
+	return call
+}
+
+// fetchAndStore fetch a new access token into the store.
+// Unless forced, token refreshed meanwhile by another caller or replica is used instead.
+func (m *tokenManager) fetchAndStore(ctx context.Context, force bool) (*AuthToken, error) {
+	if locker, ok := m.store.(TokenLocker); ok {
+		unlock, err := locker.Lock(ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		defer unlock()
+	}
+
+	if !force {
+		token, err := m.store.Get(ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+
+		m.mutex.Lock()
+		usable := m.usableLocked(token)
+		m.mutex.Unlock()
+		if usable {
+			return &AuthToken{AccessToken: token.AccessToken}, nil
+		}
+	}
+
+	dtoResp, err := m.fetch(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if dtoResp.AccessToken == "" {
+		return dtoResp, nil
+	}
+
+	token := Token{AccessToken: dtoResp.AccessToken, IssuedAt: m.now()}
+	if dtoResp.ExpiresIn > 0 {
+		token.ExpiresAt = token.IssuedAt.Add(time.Duration(dtoResp.ExpiresIn) * time.Second)
+	}
+	if err := m.store.Set(ctx, token); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return dtoResp, nil
+}
+
+// wait wait for the fetch, or until ctx of this caller is done
+func (call *tokenCall) wait(ctx context.Context) (*AuthToken, error) {
+	select {
+	case <-call.done:
+		return call.dtoResp, call.err
+	case <-ctx.Done():
+		return nil, errors.Trace(ctx.Err())
+	}
+}
+
+// detachedContext carries values of its parent, but not its deadline and cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
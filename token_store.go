@@ -0,0 +1,155 @@
+package bca
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// Token represents access token kept in TokenStore
+type Token struct {
+	AccessToken string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time // zero if BCA does not tell its expiry
+}
+
+// TokenStore keeps access token.
+// Share one TokenStore (e.g. backed by redis) between service replicas to share one access token.
+type TokenStore interface {
+	// Get return stored token, nil if there is none
+	Get(ctx context.Context) (*Token, error)
+	Set(ctx context.Context, token Token) error
+}
+
+// TokenLocker is optionally implemented by TokenStore to let only one replica refresh access token at a time
+type TokenLocker interface {
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// MemoryTokenStore keeps access token in process memory, it is the default TokenStore
+type MemoryTokenStore struct {
+	mutex sync.RWMutex
+	token *Token
+}
+
+// NewMemoryTokenStore return new instance of MemoryTokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Get return stored token, nil if there is none
+func (s *MemoryTokenStore) Get(ctx context.Context) (*Token, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.token == nil {
+		return nil, nil
+	}
+	token := *s.token
+	return &token, nil
+}
+
+// Set store token
+func (s *MemoryTokenStore) Set(ctx context.Context, token Token) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.token = &token
+	return nil
+}
+
+const (
+	lockFilePoll  = 50 * time.Millisecond
+	lockFileStale = 30 * time.Second
+)
+
+// FileTokenStore keeps access token in a JSON file, e.g. on a volume shared by service replicas.
+// Refresh is serialized using a lock file next to it.
+type FileTokenStore struct {
+	path string
+}
+
+// NewFileTokenStore return new instance of FileTokenStore
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Get return stored token, nil if there is none
+func (s *FileTokenStore) Get(ctx context.Context) (*Token, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &token, nil
+}
+
+// Set store token, the file is replaced atomically
+func (s *FileTokenStore) Set(ctx context.Context, token Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return errors.Trace(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.Chmod(tmpFile.Name(), 0600); err != nil {
+		return errors.Trace(err)
+	}
+
+	return errors.Trace(os.Rename(tmpFile.Name(), s.path))
+}
+
+// Lock acquire the lock file, lock file older than 30 seconds is considered abandoned
+func (s *FileTokenStore) Lock(ctx context.Context) (unlock func(), err error) {
+	return lockFile(ctx, s.path+".lock")
+}
+
+// lockFile acquire lock by exclusively creating lockPath, lock file older than 30 seconds is considered abandoned
+func lockFile(ctx context.Context, lockPath string) (unlock func(), err error) {
+	for {
+		lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			lockFile.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Trace(err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > lockFileStale {
+			os.Remove(lockPath)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Trace(ctx.Err())
+		case <-time.After(lockFilePoll):
+		}
+	}
+}
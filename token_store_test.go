@@ -0,0 +1,75 @@
+package bca
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bca-token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	store := NewFileTokenStore(filepath.Join(dir, "token.json"))
+
+	token, err := store.Get(ctx)
+	require.NoError(t, err)
+	require.Nil(t, token)
+
+	givenToken := Token{
+		AccessToken: "lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS",
+		IssuedAt:    time.Date(2020, 2, 3, 10, 0, 0, 0, time.UTC),
+		ExpiresAt:   time.Date(2020, 2, 3, 11, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, store.Set(ctx, givenToken))
+
+	token, err = store.Get(ctx)
+	require.NoError(t, err)
+	require.Equal(t, givenToken.AccessToken, token.AccessToken)
+	require.True(t, givenToken.ExpiresAt.Equal(token.ExpiresAt))
+
+	unlock, err := store.Lock(ctx)
+	require.NoError(t, err)
+
+	lockCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = store.Lock(lockCtx)
+	require.Error(t, err)
+
+	unlock()
+	unlock, err = store.Lock(ctx)
+	require.NoError(t, err)
+	unlock()
+}
+
+func Test_tokenManager_sharedStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bca-token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var fetchCount int32
+	fetch := func(ctx context.Context) (*AuthToken, error) {
+		atomic.AddInt32(&fetchCount, 1)
+		return &AuthToken{AccessToken: "lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS", ExpiresIn: 3600}, nil
+	}
+
+	// each replica has its own store instance of the same file
+	replica1 := newTokenManager(fetch, NewFileTokenStore(filepath.Join(dir, "token.json")), 0)
+	replica2 := newTokenManager(fetch, NewFileTokenStore(filepath.Join(dir, "token.json")), 0)
+
+	accessToken1, err := replica1.token(context.Background())
+	require.NoError(t, err)
+	accessToken2, err := replica2.token(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, accessToken1, accessToken2)
+	require.EqualValues(t, 1, atomic.LoadInt32(&fetchCount))
+}
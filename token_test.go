@@ -0,0 +1,161 @@
+package bca
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	bcaCtx "github.com/purwaren/bca-api/context"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tokenManager_singleFlight(t *testing.T) {
+	var fetchCount int32
+	m := newTokenManager(func(ctx context.Context) (*AuthToken, error) {
+		n := atomic.AddInt32(&fetchCount, 1)
+		time.Sleep(10 * time.Millisecond)
+		return &AuthToken{AccessToken: fmt.Sprintf("token-%d", n), ExpiresIn: 3600}, nil
+	}, nil, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			accessToken, err := m.token(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, "token-1", accessToken)
+		}()
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 1, atomic.LoadInt32(&fetchCount))
+}
+
+func Test_tokenManager_cancelledCaller(t *testing.T) {
+	release := make(chan struct{})
+	m := newTokenManager(func(ctx context.Context) (*AuthToken, error) {
+		require.Equal(t, "httpReqID01", ctx.Value(bcaCtx.HTTPReqIDKey))
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return &AuthToken{AccessToken: "token-1", ExpiresIn: 3600}, nil
+	}, nil, 0)
+
+	// the first caller starts the shared fetch and gives up
+	ctx, cancel := context.WithCancel(bcaCtx.With(context.Background(), bcaCtx.HTTPReqID("httpReqID01")))
+	firstErr := make(chan error)
+	go func() {
+		_, err := m.token(ctx)
+		firstErr <- err
+	}()
+	for {
+		m.mutex.Lock()
+		started := m.inflight != nil
+		m.mutex.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	secondToken := make(chan string)
+	go func() {
+		accessToken, err := m.token(context.Background())
+		require.NoError(t, err)
+		secondToken <- accessToken
+	}()
+
+	cancel()
+	require.Equal(t, context.Canceled, errors.Cause(<-firstErr))
+
+	close(release)
+	require.Equal(t, "token-1", <-secondToken)
+}
+
+func Test_tokenManager_refreshAhead(t *testing.T) {
+	now := time.Date(2020, 2, 3, 10, 0, 0, 0, time.UTC)
+
+	var fetchCount int32
+	m := newTokenManager(func(ctx context.Context) (*AuthToken, error) {
+		n := atomic.AddInt32(&fetchCount, 1)
+		return &AuthToken{AccessToken: fmt.Sprintf("token-%d", n), ExpiresIn: 3600}, nil
+	}, nil, time.Minute)
+	m.now = func() time.Time { return now }
+
+	accessToken, err := m.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", accessToken)
+
+	now = now.Add(58 * time.Minute)
+	accessToken, err = m.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", accessToken)
+
+	// within refresh window
+	now = now.Add(time.Minute + time.Second)
+	accessToken, err = m.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-2", accessToken)
+
+	// stale token is not invalidated
+	m.invalidate("token-1")
+	accessToken, err = m.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-2", accessToken)
+
+	m.invalidate("token-2")
+	accessToken, err = m.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-3", accessToken)
+}
+
+func Test_tokenManager_refreshDuringFetch(t *testing.T) {
+	release := make(chan struct{})
+	var fetchCount int32
+	m := newTokenManager(func(ctx context.Context) (*AuthToken, error) {
+		n := atomic.AddInt32(&fetchCount, 1)
+		if n == 1 {
+			<-release
+		}
+		return &AuthToken{AccessToken: fmt.Sprintf("token-%d", n), ExpiresIn: 3600}, nil
+	}, nil, 0)
+
+	// a normal fetch is running when BCA refuses the token
+	firstToken := make(chan string)
+	go func() {
+		accessToken, _ := m.token(context.Background())
+		firstToken <- accessToken
+	}()
+	for {
+		m.mutex.Lock()
+		started := m.inflight != nil
+		m.mutex.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	refreshed := make(chan *AuthToken)
+	refreshErr := make(chan error)
+	go func() {
+		dtoResp, err := m.refresh(context.Background())
+		refreshed <- dtoResp
+		refreshErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	require.Equal(t, "token-1", <-firstToken)
+	dtoResp := <-refreshed
+	require.NoError(t, <-refreshErr)
+	require.Equal(t, "token-2", dtoResp.AccessToken)
+	require.EqualValues(t, 2, atomic.LoadInt32(&fetchCount))
+}
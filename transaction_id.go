@@ -0,0 +1,180 @@
+package bca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// maxTransactionSequence is the largest sequence fitting 8 digits TransactionID
+const maxTransactionSequence = 99999999
+
+// transactionDateLayout is layout of TransactionDate (yyyy-MM-dd)
+const transactionDateLayout = "2006-01-02"
+
+// ErrNoTransactionIDGenerator is returned when TransactionID or TransactionDate of transfer is empty
+// and Config.TransactionIDGenerator is not set
+var ErrNoTransactionIDGenerator = errors.New("TransactionID and TransactionDate must be set when Config.TransactionIDGenerator is not set")
+
+// jakarta is Asia/Jakarta (WIB) time zone which BCA operates in, it has no daylight saving time
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+// SequenceSource provides sequence number which restarts every day
+type SequenceSource interface {
+	// Next return next sequence number of day (yyyy-MM-dd), starting from 1
+	Next(ctx context.Context, day string) (uint64, error)
+}
+
+// TransactionIDGenerator generates TransactionID (8 digits, unique per corporate per day)
+// and TransactionDate (yyyy-MM-dd in Asia/Jakarta) of transfers
+type TransactionIDGenerator struct {
+	sequence SequenceSource
+	now      func() time.Time
+}
+
+// NewTransactionIDGenerator return new instance of TransactionIDGenerator.
+// Share one SequenceSource between service replicas using the same CorporateID.
+func NewTransactionIDGenerator(sequence SequenceSource) *TransactionIDGenerator {
+	return &TransactionIDGenerator{sequence: sequence, now: time.Now}
+}
+
+// Today return today's TransactionDate in Asia/Jakarta
+func (g *TransactionIDGenerator) Today() string {
+	return g.now().In(jakarta).Format(transactionDateLayout)
+}
+
+// NextID return next TransactionID of transactionDate
+func (g *TransactionIDGenerator) NextID(ctx context.Context, transactionDate string) (string, error) {
+	seq, err := g.sequence.Next(ctx, transactionDate)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if seq == 0 || seq > maxTransactionSequence {
+		return "", errors.Errorf("TransactionID sequence %d of %s is out of range", seq, transactionDate)
+	}
+	return fmt.Sprintf("%08d", seq), nil
+}
+
+// fill generate empty TransactionID and TransactionDate, g may be nil
+func (g *TransactionIDGenerator) fill(ctx context.Context, transactionID, transactionDate *string) error {
+	if g == nil {
+		if *transactionID == "" || *transactionDate == "" {
+			return errors.Trace(ErrNoTransactionIDGenerator)
+		}
+		return nil
+	}
+	if *transactionDate == "" {
+		*transactionDate = g.Today()
+	}
+	if *transactionID != "" {
+		return nil
+	}
+
+	id, err := g.NextID(ctx, *transactionDate)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*transactionID = id
+	return nil
+}
+
+// placeholderTransactionID return transactionID and transactionDate with empty ones replaced by valid placeholders,
+// to validate request before they are generated
+func placeholderTransactionID(transactionID, transactionDate string) (string, string) {
+	if transactionID == "" {
+		transactionID = "00000001"
+	}
+	if transactionDate == "" {
+		transactionDate = transactionDateLayout
+	}
+	return transactionID, transactionDate
+}
+
+// MemorySequence is SequenceSource kept in process memory, it restarts from 1 when the process restarts.
+// Use it only where TransactionIDs used before the restart can not collide, e.g. in tests.
+type MemorySequence struct {
+	mutex sync.Mutex
+	day   string
+	last  uint64
+}
+
+// NewMemorySequence return new instance of MemorySequence
+func NewMemorySequence() *MemorySequence {
+	return &MemorySequence{}
+}
+
+// Next return next sequence number of day, starting from 1
+func (s *MemorySequence) Next(ctx context.Context, day string) (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.day != day {
+		s.day, s.last = day, 0
+	}
+	s.last++
+	return s.last, nil
+}
+
+// FileSequence is SequenceSource kept in a JSON file, e.g. on a volume shared by service replicas.
+// Access is serialized using a lock file next to it.
+type FileSequence struct {
+	path string
+}
+
+// fileSequenceState is content of FileSequence file
+type fileSequenceState struct {
+	Day  string
+	Last uint64
+}
+
+// NewFileSequence return new instance of FileSequence
+func NewFileSequence(path string) *FileSequence {
+	return &FileSequence{path: path}
+}
+
+// Next return next sequence number of day, starting from 1
+func (s *FileSequence) Next(ctx context.Context, day string) (uint64, error) {
+	unlock, err := lockFile(ctx, s.path+".lock")
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	defer unlock()
+
+	var state fileSequenceState
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, errors.Trace(err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return 0, errors.Annotatef(err, "sequence %s", s.path)
+		}
+	}
+
+	if state.Day != day {
+		state = fileSequenceState{Day: day}
+	}
+	state.Last++
+
+	data, err = json.Marshal(state)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(s.path), "."+filepath.Base(s.path)+".tmp")
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return 0, errors.Trace(err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return 0, errors.Trace(err)
+	}
+
+	return state.Last, nil
+}
@@ -0,0 +1,105 @@
+package bca
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionIDGenerator(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "bca-sequence")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sequences := map[string]SequenceSource{
+		"MemorySequence": NewMemorySequence(),
+		"FileSequence":   NewFileSequence(filepath.Join(dir, "sequence.json")),
+	}
+	for name, sequence := range sequences {
+		t.Run(name, func(t *testing.T) {
+			g := NewTransactionIDGenerator(sequence)
+
+			// 2020-01-30T18:00:00Z is already 2020-01-31 in Asia/Jakarta
+			g.now = func() time.Time { return time.Date(2020, 1, 30, 18, 0, 0, 0, time.UTC) }
+			require.Equal(t, "2020-01-31", g.Today())
+
+			var transactionID, transactionDate string
+			require.NoError(t, g.fill(ctx, &transactionID, &transactionDate))
+			require.Equal(t, "00000001", transactionID)
+			require.Equal(t, "2020-01-31", transactionDate)
+
+			transactionID = ""
+			require.NoError(t, g.fill(ctx, &transactionID, &transactionDate))
+			require.Equal(t, "00000002", transactionID)
+
+			// sequence restarts on the next day
+			transactionID, transactionDate = "", "2020-02-01"
+			require.NoError(t, g.fill(ctx, &transactionID, &transactionDate))
+			require.Equal(t, "00000001", transactionID)
+
+			// given TransactionID is kept
+			transactionID, transactionDate = "12345678", ""
+			require.NoError(t, g.fill(ctx, &transactionID, &transactionDate))
+			require.Equal(t, "12345678", transactionID)
+			require.Equal(t, "2020-01-31", transactionDate)
+		})
+	}
+}
+
+func TestBCA_BankingFundTransfer_generateTransactionID(t *testing.T) {
+	var transferCount int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oauth/token" {
+			_, _ = w.Write([]byte(`{"access_token":"lIWOt2p29grUo59bedBUrBY3pnzqQX544LzYPohcGHOuwn8AUEdUKS","expires_in":3600}`))
+			return
+		}
+		transferCount++
+		_, _ = w.Write([]byte(`{"ReferenceID":"12345/PO/2016","Status":"Success"}`))
+	}))
+	defer srv.Close()
+
+	g := NewTransactionIDGenerator(NewMemorySequence())
+	// 2020-01-30 23:59:59 in Asia/Jakarta
+	g.now = func() time.Time { return time.Date(2020, 1, 30, 16, 59, 59, 0, time.UTC) }
+	b := New(Config{URL: srv.URL, CorporateID: "BCAAPI2016", TransactionIDGenerator: g})
+
+	dtoReq := FundTransferRequest{
+		SourceAccountNumber:      "0201245680",
+		ReferenceID:              "12345/PO/2016",
+		CurrencyCode:             "IDR",
+		Amount:                   NewAmount(100000, 0),
+		BeneficiaryAccountNumber: "0201245681",
+	}
+	dtoResp, err := b.BankingFundTransfer(context.Background(), dtoReq)
+	require.NoError(t, err)
+	require.Equal(t, "00000001", dtoResp.TransactionID)
+	require.Equal(t, "2020-01-30", dtoResp.TransactionDate)
+
+	t.Run("invalid request does not consume sequence", func(t *testing.T) {
+		invalidReq := dtoReq
+		invalidReq.SourceAccountNumber = "123"
+		_, err := b.BankingFundTransfer(context.Background(), invalidReq)
+		require.Equal(t, ErrorClassValidation, ClassOf(err))
+
+		dtoResp, err := b.BankingFundTransfer(context.Background(), dtoReq)
+		require.NoError(t, err)
+		require.Equal(t, "00000002", dtoResp.TransactionID)
+	})
+
+	t.Run("without generator", func(t *testing.T) {
+		b := New(Config{URL: srv.URL, CorporateID: "BCAAPI2016"})
+		_, err := b.BankingFundTransfer(context.Background(), dtoReq)
+		require.Equal(t, ErrNoTransactionIDGenerator, errors.Cause(err))
+		require.Equal(t, 2, transferCount)
+	})
+}
@@ -0,0 +1,263 @@
+package bca
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/juju/errors"
+	bcaCtx "github.com/purwaren/bca-api/context"
+	"github.com/purwaren/bca-api/logger"
+)
+
+// maxVARequestSize is maximum body size of inbound VA request
+const maxVARequestSize = 1 << 20
+
+// Represent status of VA inquiry (InquiryStatus) and payment (PaymentFlagStatus)
+const (
+	VAStatusSuccess = "00"
+	VAStatusFailed  = "01"
+)
+
+// Represent reasons of rejected VA inquiry or payment
+var (
+	ErrVABillNotFound   = &VAError{Reason: ReasonMessage{Indonesian: "Tagihan tidak ditemukan", English: "Bill not found"}}
+	ErrVABillPaid       = &VAError{Reason: ReasonMessage{Indonesian: "Tagihan sudah dibayar", English: "Bill has been paid"}}
+	ErrVABillExpired    = &VAError{Reason: ReasonMessage{Indonesian: "Tagihan sudah kedaluwarsa", English: "Bill has expired"}}
+	ErrVAInvalidRequest = &VAError{Reason: ReasonMessage{Indonesian: "Permintaan tidak valid", English: "Invalid request"}}
+	ErrVAInvalidAmount  = &VAError{Reason: ReasonMessage{Indonesian: "Jumlah pembayaran tidak valid", English: "Invalid payment amount"}}
+	ErrVAGeneral        = &VAError{Reason: ReasonMessage{Indonesian: "Terjadi kesalahan, silakan coba lagi", English: "General error, please try again"}}
+)
+
+var vaReasonSuccess = ReasonMessage{Indonesian: "Sukses", English: "Success"}
+
+// VAError represents rejection of VA inquiry or payment with bilingual reason shown to the customer
+type VAError struct {
+	Reason ReasonMessage
+}
+
+func (e *VAError) Error() string {
+	return e.Reason.English
+}
+
+// vaReason return reason of err, unexpected error is reported as ErrVAGeneral
+func vaReason(err error) ReasonMessage {
+	var vaErr *VAError
+	if findError(err, func(err error) bool {
+		vaErr, _ = err.(*VAError)
+		return vaErr != nil
+	}) {
+		return vaErr.Reason
+	}
+	return ErrVAGeneral.Reason
+}
+
+// BillPaymentType represents how bill of VA customer may be paid
+type BillPaymentType int
+
+// Represent bill payment types
+const (
+	BillPaymentFull    BillPaymentType = iota // PaidAmount equals TotalAmount, or sum of the selected DetailBills
+	BillPaymentPartial                        // PaidAmount is up to TotalAmount
+	BillPaymentMinimum                        // PaidAmount is from MinimumAmount up to TotalAmount
+	BillPaymentOpen                           // any PaidAmount
+)
+
+// Bill represents bill of VA customer, DetailBills lists bills of multi-bill inquiry
+type Bill struct {
+	CustomerName string
+	CurrencyCode string
+	// TotalAmount is sum of DetailBills if zero
+	TotalAmount    Amount
+	SubCompany     string
+	DetailBills    []DetailBill
+	FreeTexts      []ReasonMessage
+	AdditionalData string
+	PaymentType    BillPaymentType
+	// MinimumAmount is minimum PaidAmount of BillPaymentMinimum
+	MinimumAmount Amount
+}
+
+// totalAmount return TotalAmount of the bill
+func (b *Bill) totalAmount() Amount {
+	if b.TotalAmount != 0 {
+		return b.TotalAmount
+	}
+
+	var total Amount
+	for _, detailBill := range b.DetailBills {
+		total = total.Add(detailBill.BillAmount)
+	}
+	return total
+}
+
+// BillProvider provides bills of VA customers
+type BillProvider interface {
+	// InquiryBill return bill of the customer.
+	// Return *VAError (e.g. ErrVABillNotFound) to reject the inquiry with its reason, nil bill is ErrVABillNotFound.
+	InquiryBill(ctx context.Context, dtoReq InquiryBillRequest) (*Bill, error)
+}
+
+// VAAuthenticator authenticates inbound BCA VA request.
+// Body of the request can be read, it is restored before being decoded.
+type VAAuthenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+// VAAuthenticatorFunc is an adapter to use ordinary function as VAAuthenticator
+type VAAuthenticatorFunc func(r *http.Request) error
+
+// Authenticate calls f(r)
+func (f VAAuthenticatorFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+// VAHandler serves BCA VA inquiry bills request
+type VAHandler struct {
+	bills BillProvider
+	auth  VAAuthenticator
+}
+
+// NewVAHandler return http.Handler of BCA VA inquiry bills request, nil auth rejects every request
+func NewVAHandler(bills BillProvider, auth VAAuthenticator) *VAHandler {
+	return &VAHandler{bills: bills, auth: auth}
+}
+
+func (h *VAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var dtoReq InquiryBillRequest
+	if !readVARequest(w, r, h.auth, &dtoReq) {
+		return
+	}
+
+	ctx := bcaCtx.With(r.Context(), bcaCtx.HTTPReqID(dtoReq.RequestID))
+
+	logger.Logger(ctx).Info("=== START VA INQUIRY_BILLS ===")
+	logger.Logger(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	dtoResp := h.inquiryBill(ctx, dtoReq)
+
+	logger.Logger(ctx).Infof("RESPONSE: %+v", dtoResp)
+	logger.Logger(ctx).Info("=== END VA INQUIRY_BILLS ===")
+
+	writeVAResponse(w, http.StatusOK, dtoResp)
+}
+
+// findBill inquire bill of the customer from bills, nil bill is ErrVABillNotFound
+func findBill(ctx context.Context, bills BillProvider, dtoReq InquiryBillRequest) (*Bill, error) {
+	bill, err := bills.InquiryBill(ctx, dtoReq)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if bill == nil {
+		return nil, errors.Annotatef(ErrVABillNotFound, "no bill of customer %s", dtoReq.CustomerNumber)
+	}
+	return bill, nil
+}
+
+func (h *VAHandler) inquiryBill(ctx context.Context, dtoReq InquiryBillRequest) InquiryBillResponse {
+	dtoResp := InquiryBillResponse{
+		CompanyCode:    dtoReq.CompanyCode,
+		CustomerNumber: dtoReq.CustomerNumber,
+		RequestID:      dtoReq.RequestID,
+		InquiryStatus:  VAStatusFailed,
+		AdditionalData: dtoReq.AdditionalData,
+		DetailBills:    []DetailBill{},
+		FreeTexts:      []ReasonMessage{},
+	}
+
+	if err := validateVARequest(dtoReq.Validate(), dtoReq.ValidateTransDate()); err != nil {
+		logger.Logger(ctx).Error(errors.Details(err))
+		dtoResp.InquiryReason = vaReason(err)
+		return dtoResp
+	}
+
+	bill, err := findBill(ctx, h.bills, dtoReq)
+	if err != nil {
+		logger.Logger(ctx).Error(errors.Details(err))
+		dtoResp.InquiryReason = vaReason(err)
+		return dtoResp
+	}
+
+	dtoResp.InquiryStatus = VAStatusSuccess
+	dtoResp.InquiryReason = vaReasonSuccess
+	dtoResp.CustomerName = bill.CustomerName
+	dtoResp.CurrencyCode = bill.CurrencyCode
+	dtoResp.TotalAmount = bill.totalAmount()
+	dtoResp.SubCompany = bill.SubCompany
+	if bill.DetailBills != nil {
+		dtoResp.DetailBills = bill.DetailBills
+	}
+	if bill.FreeTexts != nil {
+		dtoResp.FreeTexts = bill.FreeTexts
+	}
+	if bill.AdditionalData != "" {
+		dtoResp.AdditionalData = bill.AdditionalData
+	}
+	return dtoResp
+}
+
+// validateVARequest return ErrVAInvalidRequest annotated with the first validation error, if any
+func validateVARequest(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return errors.Annotate(ErrVAInvalidRequest, err.Error())
+		}
+	}
+	return nil
+}
+
+// readVARequest authenticate and decode inbound VA request into dtoReq, error response is written on failure
+func readVARequest(w http.ResponseWriter, r *http.Request, auth VAAuthenticator, dtoReq interface{}) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeVAResponse(w, http.StatusMethodNotAllowed, Error{
+			ErrorCode:    ErrCodeInvalidRequest,
+			ErrorMessage: ErrorLang{Indonesian: "Metode tidak diizinkan", English: "Method not allowed"},
+		})
+		return false
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxVARequestSize))
+	if err != nil {
+		logger.Logger(r.Context()).Error(errors.Details(err))
+		writeVAResponse(w, http.StatusBadRequest, Error{
+			ErrorCode:    ErrCodeInvalidRequest,
+			ErrorMessage: ErrorLang{Indonesian: "Permintaan tidak valid", English: "Invalid request"},
+		})
+		return false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if auth == nil {
+		err = errors.Unauthorizedf("no VA authenticator")
+	} else {
+		err = auth.Authenticate(r)
+	}
+	if err != nil {
+		logger.Logger(r.Context()).Error(errors.Details(err))
+		status, dtoErr := vaAuthErrorResponse(err)
+		writeVAResponse(w, status, dtoErr)
+		return false
+	}
+
+	if err := json.Unmarshal(body, dtoReq); err != nil {
+		logger.Logger(r.Context()).Error(errors.Details(err))
+		writeVAResponse(w, http.StatusBadRequest, Error{
+			ErrorCode:    ErrCodeInvalidJSON,
+			ErrorMessage: ErrorLang{Indonesian: "Format JSON tidak valid", English: "Invalid JSON format"},
+		})
+		return false
+	}
+
+	return true
+}
+
+func writeVAResponse(w http.ResponseWriter, status int, dtoResp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(dtoResp); err != nil {
+		logger.Error(errors.Details(err))
+	}
+}
@@ -0,0 +1,230 @@
+package bca
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/purwaren/bca-api/logger"
+)
+
+// ErrInvalidAPIKey rejects inbound VA request of which X-BCA-Key is not the configured API key
+var ErrInvalidAPIKey = errors.New("invalid X-BCA-Key")
+
+// DefaultVATokenTTL is default lifetime of access token issued to BCA
+const DefaultVATokenTTL = time.Hour
+
+// vaTokenScope is scope of access token issued to BCA
+const vaTokenScope = "resource.WRITE resource.READ"
+
+// VAAuthenticators return VAAuthenticator which requires all of auths to authenticate the request
+func VAAuthenticators(auths ...VAAuthenticator) VAAuthenticator {
+	return VAAuthenticatorFunc(func(r *http.Request) error {
+		for _, auth := range auths {
+			if err := auth.Authenticate(r); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+}
+
+// VATokenIssuer issues access tokens to BCA calling VA callbacks, it serves POST /api/oauth/token
+// with client credentials grant. It is a VAAuthenticator accepting the issued tokens.
+type VATokenIssuer struct {
+	clientID     string
+	clientSecret string
+	ttl          time.Duration
+	now          func() time.Time
+
+	mutex  sync.Mutex
+	tokens map[string]time.Time // access token to its expiry
+}
+
+// NewVATokenIssuer return new instance of VATokenIssuer, zero ttl means DefaultVATokenTTL
+func NewVATokenIssuer(clientID, clientSecret string, ttl time.Duration) *VATokenIssuer {
+	if ttl <= 0 {
+		ttl = DefaultVATokenTTL
+	}
+	return &VATokenIssuer{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		ttl:          ttl,
+		now:          time.Now,
+		tokens:       map[string]time.Time{},
+	}
+}
+
+func (i *VATokenIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeVAResponse(w, http.StatusMethodNotAllowed, Error{
+			ErrorCode:    ErrCodeInvalidRequest,
+			ErrorMessage: ErrorLang{Indonesian: "Metode tidak diizinkan", English: "Method not allowed"},
+		})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || r.PostFormValue("grant_type") != "client_credentials" ||
+		subtle.ConstantTimeCompare([]byte(clientID), []byte(i.clientID)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(clientSecret), []byte(i.clientSecret)) != 1 {
+		logger.Logger(r.Context()).Errorf("invalid client credentials of client_id %q", clientID)
+		writeVAResponse(w, http.StatusUnauthorized, Error{
+			ErrorCode: ErrCodeInvalidClient,
+			ErrorMessage: ErrorLang{
+				Indonesian: "client_id/client_secret/grant_type tidak valid",
+				English:    "Invalid client_id/client_secret/grant_type",
+			},
+		})
+		return
+	}
+
+	accessToken, err := i.issue()
+	if err != nil {
+		logger.Logger(r.Context()).Error(errors.Details(err))
+		writeVAResponse(w, http.StatusInternalServerError, Error{
+			ErrorCode:    ErrCodeSystemUnavailable,
+			ErrorMessage: ErrorLang{Indonesian: "Sistem sedang tidak tersedia", English: "System unavailable"},
+		})
+		return
+	}
+
+	writeVAResponse(w, http.StatusOK, struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}{accessToken, "Bearer", int(i.ttl / time.Second), vaTokenScope})
+}
+
+func (i *VATokenIssuer) issue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Trace(err)
+	}
+	accessToken := base64.RawURLEncoding.EncodeToString(b)
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	now := i.now()
+	for token, expiresAt := range i.tokens {
+		if !now.Before(expiresAt) {
+			delete(i.tokens, token)
+		}
+	}
+	i.tokens[accessToken] = now.Add(i.ttl)
+	return accessToken, nil
+}
+
+// Authenticate accept request bearing unexpired access token issued by i
+func (i *VATokenIssuer) Authenticate(r *http.Request) error {
+	accessToken, ok := bearerToken(r)
+	if !ok {
+		return errors.Unauthorizedf("no bearer access token")
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	expiresAt, ok := i.tokens[accessToken]
+	if !ok {
+		return errors.Unauthorizedf("unknown access token")
+	}
+	if !i.now().Before(expiresAt) {
+		delete(i.tokens, accessToken)
+		return errors.Unauthorizedf("access token expired at %s", expiresAt)
+	}
+	return nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", false
+	}
+	return auth[len(prefix):], true
+}
+
+// VASignature verifies X-BCA-Key, X-BCA-Timestamp and X-BCA-Signature of inbound VA requests,
+// which BCA signs the same way GenerateSignature signs outbound requests
+type VASignature struct {
+	APIKey    string
+	APISecret string
+	// MaxClockSkew is maximum difference between X-BCA-Timestamp and local time, zero means DefaultMaxClockSkew
+	MaxClockSkew time.Duration
+	// ReplayCache rejects replayed requests, nil disables replay protection
+	ReplayCache ReplayCache
+}
+
+// Authenticate verify signature of r, body of r is restored after being read.
+// Body larger than maxVARequestSize is rejected before it is verified.
+func (s VASignature) Authenticate(r *http.Request) error {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-BCA-Key")), []byte(s.APIKey)) != 1 {
+		return errors.Trace(ErrInvalidAPIKey)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxVARequestSize)); err != nil {
+			return errors.Annotatef(err, "request body of at most %d bytes", maxVARequestSize)
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	accessToken, _ := bearerToken(r)
+	return errors.Trace(VerifySignature(s.APISecret, r.Method, r.URL.RequestURI(), accessToken, string(body),
+		r.Header.Get("X-BCA-Timestamp"), r.Header.Get("X-BCA-Signature"),
+		VerifyOptions{MaxClockSkew: s.MaxClockSkew, ReplayCache: s.ReplayCache}))
+}
+
+// Middleware return handler which verifies signature of the request before passing it to next
+func (s VASignature) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxVARequestSize)
+		}
+		if err := s.Authenticate(r); err != nil {
+			logger.Logger(r.Context()).Error(errors.Details(err))
+			status, dtoErr := vaAuthErrorResponse(err)
+			writeVAResponse(w, status, dtoErr)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// vaAuthErrorResponse return HTTP status and BCA error response of failed authentication
+func vaAuthErrorResponse(err error) (int, Error) {
+	switch errors.Cause(err) {
+	case ErrInvalidAPIKey:
+		return http.StatusUnauthorized, Error{
+			ErrorCode:    ErrCodeInvalidAPIKey,
+			ErrorMessage: ErrorLang{Indonesian: "API Key tidak valid", English: "Invalid API Key"},
+		}
+	case ErrInvalidTimestamp, ErrTimestampSkew:
+		return http.StatusBadRequest, Error{
+			ErrorCode:    ErrCodeInvalidTimestamp,
+			ErrorMessage: ErrorLang{Indonesian: "Timestamp tidak valid", English: "Invalid timestamp"},
+		}
+	case ErrSignatureMismatch:
+		return http.StatusUnauthorized, Error{
+			ErrorCode:    ErrCodeHMACMismatch,
+			ErrorMessage: ErrorLang{Indonesian: "HMAC tidak cocok", English: "HMAC mismatch"},
+		}
+	}
+	return http.StatusUnauthorized, Error{
+		ErrorCode:    ErrCodeUnauthorized,
+		ErrorMessage: ErrorLang{Indonesian: "Tidak berhak", English: "Unauthorized"},
+	}
+}
@@ -0,0 +1,122 @@
+package bca_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/purwaren/bca-api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVATokenIssuer(t *testing.T) {
+	issuer := bca.NewVATokenIssuer("client-id", "client-secret", time.Minute)
+	signature := bca.VASignature{APIKey: "api-key", APISecret: "api-secret"}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/oauth/token", issuer)
+	mux.Handle("/va/bills", signature.Middleware(bca.NewVAHandler(billProvider{"123456789": {CustomerName: "Customer"}}, issuer)))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	getToken := func(clientSecret string) (int, map[string]interface{}) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/oauth/token", strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("client-id", clientSecret)
+		return doJSON(t, req)
+	}
+
+	status, dtoResp := getToken("wrong")
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, bca.ErrCodeInvalidClient, dtoResp["ErrorCode"])
+
+	status, dtoResp = getToken("client-secret")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "Bearer", dtoResp["token_type"])
+	require.Equal(t, float64(60), dtoResp["expires_in"])
+	accessToken := dtoResp["access_token"].(string)
+	require.NotEmpty(t, accessToken)
+
+	body := `{"CompanyCode":"12345","CustomerNumber":"123456789","RequestID":"201507131507262221400000001975",` +
+		`"ChannelType":"6014","TransactionDate":"15/03/2014 22:07:40","AdditionalData":""}`
+	inquiry := func(apiKey, accessToken string, signedAt time.Time, sign func(signature string) string) (int, map[string]interface{}) {
+		timestamp := signedAt.Format("2006-01-02T15:04:05.999Z07:00")
+		signature, _, err := bca.GenerateSignature("api-secret", http.MethodPost, "/va/bills", accessToken, body, timestamp)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/va/bills", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("X-BCA-Key", apiKey)
+		req.Header.Set("X-BCA-Timestamp", timestamp)
+		req.Header.Set("X-BCA-Signature", sign(signature))
+		return doJSON(t, req)
+	}
+	signed := func(signature string) string { return signature }
+
+	status, dtoResp = inquiry("api-key", accessToken, time.Now(), signed)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, bca.VAStatusSuccess, dtoResp["InquiryStatus"])
+
+	status, dtoResp = inquiry("other-key", accessToken, time.Now(), signed)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, bca.ErrCodeInvalidAPIKey, dtoResp["ErrorCode"])
+
+	status, dtoResp = inquiry("api-key", accessToken, time.Now().Add(-10*time.Minute), signed)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, bca.ErrCodeInvalidTimestamp, dtoResp["ErrorCode"])
+
+	status, dtoResp = inquiry("api-key", accessToken, time.Now(), func(signature string) string { return strings.Repeat("0", len(signature)) })
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, bca.ErrCodeHMACMismatch, dtoResp["ErrorCode"])
+
+	status, dtoResp = inquiry("api-key", "unknown-token", time.Now(), signed)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, bca.ErrCodeUnauthorized, dtoResp["ErrorCode"])
+}
+
+func TestVASignature_Middleware_tooLarge(t *testing.T) {
+	signature := bca.VASignature{APIKey: "api-key", APISecret: "api-secret"}
+	var served bool
+	handler := signature.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	}))
+
+	body := strings.Repeat(" ", 1<<20+1)
+	timestamp := time.Now().Format("2006-01-02T15:04:05.999Z07:00")
+	sign, _, err := bca.GenerateSignature("api-secret", http.MethodPost, "/va/bills", "token", body, timestamp)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/va/bills", strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer token")
+	r.Header.Set("X-BCA-Key", "api-key")
+	r.Header.Set("X-BCA-Timestamp", timestamp)
+	r.Header.Set("X-BCA-Signature", sign)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.False(t, served)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	var dtoResp bca.Error
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtoResp))
+	require.Equal(t, bca.ErrCodeUnauthorized, dtoResp.ErrorCode)
+}
+
+func doJSON(t *testing.T, req *http.Request) (int, map[string]interface{}) {
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var dtoResp map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &dtoResp))
+	return resp.StatusCode, dtoResp
+}
@@ -0,0 +1,369 @@
+package bca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	bcaCtx "github.com/purwaren/bca-api/context"
+	"github.com/purwaren/bca-api/logger"
+)
+
+// ErrVAPaymentInProgress rejects payment flag which is being processed by another request
+var ErrVAPaymentInProgress = &VAError{Reason: ReasonMessage{Indonesian: "Pembayaran sedang diproses", English: "Payment is being processed"}}
+
+// Payment represents result of processed VA payment
+type Payment struct {
+	// DetailBills is status of each paid bill, nil means every bill of the request has the payment status
+	DetailBills    []DetailBillPayment
+	FreeTexts      []ReasonMessage
+	AdditionalData string
+}
+
+// PaymentProcessor credits VA payments to customers
+type PaymentProcessor interface {
+	// ProcessPayment credit the payment to the customer.
+	// Return *VAError (e.g. ErrVABillPaid) to reject the payment with its reason.
+	ProcessPayment(ctx context.Context, dtoReq PaymentBillRequest) (*Payment, error)
+}
+
+// PaymentKey identifies VA payment, BCA repeats them in advice (FlagAdvice=Y) of the payment
+type PaymentKey struct {
+	RequestID string
+	Reference string
+}
+
+// PaymentStore records processed VA payments so repeated payment flags are not credited twice
+type PaymentStore interface {
+	// Begin claim key for processing. Return response of the payment if it is already processed,
+	// ErrVAPaymentInProgress if it is being processed.
+	Begin(ctx context.Context, key PaymentKey) (*PaymentBillResponse, error)
+	// Finish record response of the processed payment
+	Finish(ctx context.Context, key PaymentKey, dtoResp PaymentBillResponse) error
+	// Release remove claim of the payment which is not processed, so it can be processed again
+	Release(ctx context.Context, key PaymentKey) error
+}
+
+// DefaultPaymentRetention is default duration MemoryPaymentStore keeps payments, BCA repeats payment flag well within it
+const DefaultPaymentRetention = 24 * time.Hour
+
+// MemoryPaymentStore is PaymentStore kept in process memory, payments are forgotten after their retention.
+// It detects repeated payment flags only within a single process, replicas of the service must share a PaymentStore.
+type MemoryPaymentStore struct {
+	mutex     sync.Mutex
+	retention time.Duration
+	payments  map[PaymentKey]memoryPayment
+	expiries  []memoryPaymentExpiry // ordered by expiresAt
+}
+
+type memoryPayment struct {
+	dtoResp   *PaymentBillResponse // nil while being processed
+	expiresAt time.Time
+}
+
+type memoryPaymentExpiry struct {
+	key       PaymentKey
+	expiresAt time.Time
+}
+
+// NewMemoryPaymentStore return new instance of MemoryPaymentStore keeping payments for DefaultPaymentRetention
+func NewMemoryPaymentStore() *MemoryPaymentStore {
+	return NewMemoryPaymentStoreWithRetention(DefaultPaymentRetention)
+}
+
+// NewMemoryPaymentStoreWithRetention return new instance of MemoryPaymentStore keeping payments for retention
+func NewMemoryPaymentStoreWithRetention(retention time.Duration) *MemoryPaymentStore {
+	return &MemoryPaymentStore{retention: retention, payments: map[PaymentKey]memoryPayment{}}
+}
+
+// Begin claim key for processing. Return response of the payment if it is already processed,
+// ErrVAPaymentInProgress if it is being processed.
+func (s *MemoryPaymentStore) Begin(ctx context.Context, key PaymentKey) (*PaymentBillResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pruneLocked()
+
+	payment, ok := s.payments[key]
+	if !ok {
+		s.putLocked(key, nil)
+		return nil, nil
+	}
+	if payment.dtoResp == nil {
+		return nil, errors.Trace(ErrVAPaymentInProgress)
+	}
+	return payment.dtoResp, nil
+}
+
+// Finish record response of the processed payment
+func (s *MemoryPaymentStore) Finish(ctx context.Context, key PaymentKey, dtoResp PaymentBillResponse) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.putLocked(key, &dtoResp)
+	return nil
+}
+
+// Release remove claim of the payment which is not processed, so it can be processed again
+func (s *MemoryPaymentStore) Release(ctx context.Context, key PaymentKey) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if payment, ok := s.payments[key]; ok && payment.dtoResp == nil {
+		delete(s.payments, key)
+	}
+	return nil
+}
+
+func (s *MemoryPaymentStore) putLocked(key PaymentKey, dtoResp *PaymentBillResponse) {
+	expiresAt := time.Now().Add(s.retention)
+	s.payments[key] = memoryPayment{dtoResp: dtoResp, expiresAt: expiresAt}
+	s.expiries = append(s.expiries, memoryPaymentExpiry{key: key, expiresAt: expiresAt})
+}
+
+// pruneLocked forget expired payments
+func (s *MemoryPaymentStore) pruneLocked() {
+	now := time.Now()
+	for len(s.expiries) > 0 && !s.expiries[0].expiresAt.After(now) {
+		expiry := s.expiries[0]
+		s.expiries = s.expiries[1:]
+		if payment, ok := s.payments[expiry.key]; ok && payment.expiresAt.Equal(expiry.expiresAt) {
+			delete(s.payments, expiry.key)
+		}
+	}
+}
+
+// VAPaymentHandler serves BCA VA payment flag request
+type VAPaymentHandler struct {
+	bills    BillProvider
+	payments PaymentProcessor
+	store    PaymentStore
+	auth     VAAuthenticator
+}
+
+// NewVAPaymentHandler return http.Handler of BCA VA payment flag request, the request must pay in full.
+// Nil store means NewMemoryPaymentStore(), which is single-process only, nil auth rejects every request.
+func NewVAPaymentHandler(payments PaymentProcessor, store PaymentStore, auth VAAuthenticator) *VAPaymentHandler {
+	return NewVAPaymentHandlerWithBills(nil, payments, store, auth)
+}
+
+// NewVAPaymentHandlerWithBills return http.Handler of BCA VA payment flag request,
+// PaidAmount is validated against the bill inquired from bills (e.g. for partial, minimum or open payment).
+// Nil bills means the request must pay in full, nil store means NewMemoryPaymentStore() (single-process only),
+// nil auth rejects every request.
+func NewVAPaymentHandlerWithBills(bills BillProvider, payments PaymentProcessor, store PaymentStore, auth VAAuthenticator) *VAPaymentHandler {
+	if store == nil {
+		store = NewMemoryPaymentStore()
+	}
+	return &VAPaymentHandler{bills: bills, payments: payments, store: store, auth: auth}
+}
+
+func (h *VAPaymentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var lenientReq lenientPaymentBillRequest
+	if !readVARequest(w, r, h.auth, &lenientReq) {
+		return
+	}
+	dtoReq := lenientReq.PaymentBillRequest
+
+	ctx := bcaCtx.With(r.Context(), bcaCtx.HTTPReqID(dtoReq.RequestID))
+
+	logger.Logger(ctx).Info("=== START VA PAYMENT_FLAG ===")
+	logger.Logger(ctx).Infof("REQUEST: %+v", dtoReq)
+
+	var dtoResp PaymentBillResponse
+	if lenientReq.amountErr != nil {
+		err := errors.Annotate(ErrVAInvalidAmount, lenientReq.amountErr.Error())
+		logger.Logger(ctx).Error(errors.Details(err))
+		dtoResp = paymentResponse(dtoReq, nil, err)
+	} else {
+		dtoResp = h.processPayment(ctx, dtoReq)
+	}
+
+	logger.Logger(ctx).Infof("RESPONSE: %+v", dtoResp)
+	logger.Logger(ctx).Info("=== END VA PAYMENT_FLAG ===")
+
+	writeVAResponse(w, http.StatusOK, dtoResp)
+}
+
+func (h *VAPaymentHandler) processPayment(ctx context.Context, dtoReq PaymentBillRequest) PaymentBillResponse {
+	if err := validateVARequest(dtoReq.Validate(), dtoReq.ValidateTransDate(), dtoReq.ValidateFlagAdvice()); err != nil {
+		logger.Logger(ctx).Error(errors.Details(err))
+		return paymentResponse(dtoReq, nil, err)
+	}
+
+	key := PaymentKey{RequestID: dtoReq.RequestID, Reference: dtoReq.Reference}
+	processed, err := h.store.Begin(ctx, key)
+	if err != nil {
+		logger.Logger(ctx).Error(errors.Details(err))
+		return paymentResponse(dtoReq, nil, err)
+	}
+	if processed != nil {
+		logger.Logger(ctx).Infof("payment %+v is already processed", key)
+		return *processed
+	}
+
+	var payment *Payment
+	err = h.validatePaidAmount(ctx, dtoReq)
+	if err == nil {
+		payment, err = h.payments.ProcessPayment(ctx, dtoReq)
+	}
+	if err != nil {
+		logger.Logger(ctx).Error(errors.Details(err))
+		if err := h.store.Release(ctx, key); err != nil {
+			logger.Logger(ctx).Error(errors.Details(err))
+		}
+		return paymentResponse(dtoReq, nil, err)
+	}
+
+	dtoResp := paymentResponse(dtoReq, payment, nil)
+	if err := h.store.Finish(ctx, key, dtoResp); err != nil {
+		// the payment is credited, only its repetition can not be detected
+		logger.Logger(ctx).Error(errors.Details(err))
+	}
+	return dtoResp
+}
+
+// validatePaidAmount check PaidAmount against the bill and its payment type
+func (h *VAPaymentHandler) validatePaidAmount(ctx context.Context, dtoReq PaymentBillRequest) error {
+	if dtoReq.PaidAmount <= 0 {
+		return errors.Annotatef(ErrVAInvalidAmount, "PaidAmount %s", dtoReq.PaidAmount)
+	}
+
+	bill := &Bill{TotalAmount: dtoReq.TotalAmount, DetailBills: dtoReq.DetailBills}
+	if h.bills != nil {
+		var err error
+		bill, err = findBill(ctx, h.bills, InquiryBillRequest{
+			CompanyCode:     dtoReq.CompanyCode,
+			CustomerNumber:  dtoReq.CustomerNumber,
+			RequestID:       dtoReq.RequestID,
+			ChannelType:     dtoReq.ChannelType,
+			TransactionDate: dtoReq.TransactionDate,
+			AdditionalData:  dtoReq.AdditionalData,
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	dueAmount := bill.totalAmount()
+
+	// multi-bill payment pays the selected bills, whose amounts are known only from BillProvider
+	if h.bills != nil && len(dtoReq.DetailBills) > 0 && len(bill.DetailBills) > 0 {
+		selected := &Bill{}
+		for _, paid := range dtoReq.DetailBills {
+			found := false
+			for _, detailBill := range bill.DetailBills {
+				if detailBill.BillNumber == paid.BillNumber {
+					selected.DetailBills = append(selected.DetailBills, detailBill)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return errors.Annotatef(ErrVABillNotFound, "bill %s", paid.BillNumber)
+			}
+		}
+		dueAmount = selected.totalAmount()
+	}
+
+	minAmount, maxAmount := dueAmount, dueAmount
+	switch bill.PaymentType {
+	case BillPaymentPartial:
+		minAmount = NewAmount(0, 1)
+	case BillPaymentMinimum:
+		minAmount = bill.MinimumAmount
+	case BillPaymentOpen:
+		minAmount, maxAmount = NewAmount(0, 1), dtoReq.PaidAmount
+	}
+	if dtoReq.PaidAmount.Cmp(minAmount) < 0 || dtoReq.PaidAmount.Cmp(maxAmount) > 0 {
+		return errors.Annotatef(ErrVAInvalidAmount, "PaidAmount %s is out of %s..%s", dtoReq.PaidAmount, minAmount, maxAmount)
+	}
+	return nil
+}
+
+// paymentResponse return response of the payment, failed with reason of err if err is not nil
+func paymentResponse(dtoReq PaymentBillRequest, payment *Payment, err error) PaymentBillResponse {
+	status, reason := VAStatusSuccess, vaReasonSuccess
+	if err != nil {
+		status, reason = VAStatusFailed, vaReason(err)
+	}
+
+	dtoResp := PaymentBillResponse{
+		CompanyCode:       dtoReq.CompanyCode,
+		CustomerName:      dtoReq.CustomerName,
+		RequestID:         dtoReq.RequestID,
+		PaymentFlagStatus: status,
+		PaymentFlagReason: reason,
+		CustomerNumber:    dtoReq.CustomerNumber,
+		CurrencyCode:      dtoReq.CurrencyCode,
+		PaidAmount:        dtoReq.PaidAmount,
+		TotalAmount:       dtoReq.TotalAmount,
+		TransactionDate:   dtoReq.TransactionDate,
+		DetailBills:       []DetailBillPayment{},
+		FreeTexts:         []ReasonMessage{},
+		AdditionalData:    dtoReq.AdditionalData,
+	}
+	if payment != nil && payment.DetailBills != nil {
+		dtoResp.DetailBills = payment.DetailBills
+	} else {
+		for _, bill := range dtoReq.DetailBills {
+			dtoResp.DetailBills = append(dtoResp.DetailBills, DetailBillPayment{BillNumber: bill.BillNumber, Status: status, Reason: reason})
+		}
+	}
+	if payment != nil && payment.FreeTexts != nil {
+		dtoResp.FreeTexts = payment.FreeTexts
+	}
+	if payment != nil && payment.AdditionalData != "" {
+		dtoResp.AdditionalData = payment.AdditionalData
+	}
+	return dtoResp
+}
+
+// lenientPaymentBillRequest decode PaymentBillRequest keeping the first malformed amount in amountErr,
+// so the payment is rejected with ErrVAInvalidAmount in payment flag response instead of invalid JSON error
+type lenientPaymentBillRequest struct {
+	PaymentBillRequest
+	amountErr error
+}
+
+func (m *lenientPaymentBillRequest) UnmarshalJSON(data []byte) error {
+	type plainPaymentBillRequest PaymentBillRequest
+	type plainDetailBill DetailBill
+	var raw struct {
+		plainPaymentBillRequest
+		PaidAmount  json.RawMessage
+		TotalAmount json.RawMessage
+		DetailBills []struct {
+			plainDetailBill
+			BillAmount json.RawMessage
+		}
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.Trace(err)
+	}
+
+	amount := func(field string, data json.RawMessage) Amount {
+		var a Amount
+		if len(data) == 0 {
+			return a
+		}
+		if err := a.UnmarshalJSON(data); err != nil && m.amountErr == nil {
+			m.amountErr = errors.Annotate(err, field)
+		}
+		return a
+	}
+
+	m.PaymentBillRequest = PaymentBillRequest(raw.plainPaymentBillRequest)
+	m.PaidAmount = amount("PaidAmount", raw.PaidAmount)
+	m.TotalAmount = amount("TotalAmount", raw.TotalAmount)
+	m.DetailBills = nil
+	for i, detailBill := range raw.DetailBills {
+		detailBill.plainDetailBill.BillAmount = amount(fmt.Sprintf("DetailBills.%d.BillAmount", i), detailBill.BillAmount)
+		m.DetailBills = append(m.DetailBills, DetailBill(detailBill.plainDetailBill))
+	}
+	return nil
+}
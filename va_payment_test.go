@@ -0,0 +1,244 @@
+package bca_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/purwaren/bca-api"
+	"github.com/stretchr/testify/require"
+)
+
+type paymentProcessor struct {
+	mutex   sync.Mutex
+	credits map[string]int
+	reject  error
+}
+
+func (p *paymentProcessor) ProcessPayment(ctx context.Context, dtoReq bca.PaymentBillRequest) (*bca.Payment, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.reject != nil {
+		return nil, errors.Trace(p.reject)
+	}
+	p.credits[dtoReq.CustomerNumber]++
+	return &bca.Payment{}, nil
+}
+
+func TestVAPaymentHandler(t *testing.T) {
+	processor := &paymentProcessor{credits: map[string]int{}}
+	handler := bca.NewVAPaymentHandler(processor, nil, vaAuth)
+
+	payBills := func(requestID, flagAdvice, detailBills string) bca.PaymentBillResponse {
+		body := `{"CompanyCode":"12345","CustomerNumber":"123456789","RequestID":"` + requestID + `","ChannelType":"6014",` +
+			`"CustomerName":"Customer Name Virtual Account","CurrencyCode":"IDR","PaidAmount":"150000.00","TotalAmount":"150000.00",` +
+			`"SubCompany":"00000","TransactionDate":"15/03/2014 22:07:40","Reference":"1234567890",` +
+			`"DetailBills":` + detailBills + `,"FlagAdvice":"` + flagAdvice + `","AdditionalData":""}`
+		r := httptest.NewRequest(http.MethodPost, "/va/payments", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var dtoResp bca.PaymentBillResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtoResp))
+		return dtoResp
+	}
+	pay := func(requestID, flagAdvice string) bca.PaymentBillResponse {
+		return payBills(requestID, flagAdvice, `[{"BillNumber":"1","BillAmount":"150000.00"}]`)
+	}
+
+	t.Run("invalid FlagAdvice", func(t *testing.T) {
+		dtoResp := pay("201507131507262221400000001975", "X")
+		require.Equal(t, bca.VAStatusFailed, dtoResp.PaymentFlagStatus)
+		require.Equal(t, bca.ErrVAInvalidRequest.Reason, dtoResp.PaymentFlagReason)
+		require.Empty(t, processor.credits)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		processor.reject = bca.ErrVABillPaid
+		defer func() { processor.reject = nil }()
+
+		dtoResp := pay("201507131507262221400000001976", "N")
+		require.Equal(t, bca.VAStatusFailed, dtoResp.PaymentFlagStatus)
+		require.Equal(t, bca.ErrVABillPaid.Reason, dtoResp.PaymentFlagReason)
+		require.Equal(t, []bca.DetailBillPayment{{BillNumber: "1", Status: bca.VAStatusFailed, Reason: bca.ErrVABillPaid.Reason}}, dtoResp.DetailBills)
+	})
+
+	t.Run("advice is credited once", func(t *testing.T) {
+		dtoResp := pay("201507131507262221400000001977", "N")
+		require.Equal(t, bca.VAStatusSuccess, dtoResp.PaymentFlagStatus)
+		require.Equal(t, bca.NewAmount(150000, 0), dtoResp.PaidAmount)
+		require.Equal(t, []bca.DetailBillPayment{{BillNumber: "1", Status: bca.VAStatusSuccess, Reason: dtoResp.PaymentFlagReason}}, dtoResp.DetailBills)
+
+		var wg sync.WaitGroup
+		adviceResps := make([]bca.PaymentBillResponse, 10)
+		for i := range adviceResps {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				adviceResps[i] = pay("201507131507262221400000001977", "Y")
+			}(i)
+		}
+		wg.Wait()
+
+		for _, adviceResp := range adviceResps {
+			require.Equal(t, dtoResp, adviceResp)
+		}
+
+		require.Equal(t, 1, processor.credits["123456789"])
+	})
+
+	t.Run("bills without amount pay TotalAmount", func(t *testing.T) {
+		dtoResp := payBills("201507131507262221400000001978", "N", `[{"BillNumber":"1"},{"BillNumber":"2"}]`)
+		require.Equal(t, bca.VAStatusSuccess, dtoResp.PaymentFlagStatus)
+		require.Equal(t, 2, processor.credits["123456789"])
+	})
+}
+
+func TestMemoryPaymentStore(t *testing.T) {
+	ctx := context.Background()
+	store := bca.NewMemoryPaymentStore()
+	key := bca.PaymentKey{RequestID: "201507131507262221400000001975", Reference: "1234567890"}
+
+	processed, err := store.Begin(ctx, key)
+	require.NoError(t, err)
+	require.Nil(t, processed)
+
+	_, err = store.Begin(ctx, key)
+	require.Equal(t, bca.ErrVAPaymentInProgress, errors.Cause(err))
+
+	require.NoError(t, store.Release(ctx, key))
+	processed, err = store.Begin(ctx, key)
+	require.NoError(t, err)
+	require.Nil(t, processed)
+
+	require.NoError(t, store.Finish(ctx, key, bca.PaymentBillResponse{PaymentFlagStatus: bca.VAStatusSuccess}))
+	require.NoError(t, store.Release(ctx, key))
+	processed, err = store.Begin(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, bca.VAStatusSuccess, processed.PaymentFlagStatus)
+
+	t.Run("retention", func(t *testing.T) {
+		store := bca.NewMemoryPaymentStoreWithRetention(time.Millisecond)
+		_, err := store.Begin(ctx, key)
+		require.NoError(t, err)
+		require.NoError(t, store.Finish(ctx, key, bca.PaymentBillResponse{PaymentFlagStatus: bca.VAStatusSuccess}))
+
+		time.Sleep(10 * time.Millisecond)
+		processed, err := store.Begin(ctx, key)
+		require.NoError(t, err)
+		require.Nil(t, processed)
+	})
+}
+
+func TestVAPaymentHandler_paidAmount(t *testing.T) {
+	detailBills := []bca.DetailBill{
+		{BillNumber: "1", BillAmount: bca.MustParseAmount("100000.00")},
+		{BillNumber: "2", BillAmount: bca.MustParseAmount("50000.50")},
+	}
+	bills := billProvider{
+		"1": {PaymentType: bca.BillPaymentFull, DetailBills: detailBills},
+		"2": {PaymentType: bca.BillPaymentPartial, TotalAmount: bca.MustParseAmount("150000.50")},
+		"3": {PaymentType: bca.BillPaymentMinimum, TotalAmount: bca.MustParseAmount("150000.50"), MinimumAmount: bca.MustParseAmount("50000")},
+		"4": {PaymentType: bca.BillPaymentOpen},
+	}
+	processor := &paymentProcessor{credits: map[string]int{}}
+	handler := bca.NewVAPaymentHandlerWithBills(bills, processor, nil, vaAuth)
+
+	tests := []struct {
+		name           string
+		customerNumber string
+		paidAmount     string
+		detailBills    []bca.DetailBill
+		expectedReason bca.ReasonMessage
+	}{
+		{"full", "1", "150000.50", nil, bca.ReasonMessage{}},
+		{"full underpaid", "1", "150000.00", nil, bca.ErrVAInvalidAmount.Reason},
+		{"selected bill", "1", "50000.5", []bca.DetailBill{{BillNumber: "2"}}, bca.ReasonMessage{}},
+		{"selected bills overpaid", "1", "150000.00", []bca.DetailBill{{BillNumber: "1"}}, bca.ErrVAInvalidAmount.Reason},
+		{"unknown selected bill", "1", "100000.00", []bca.DetailBill{{BillNumber: "3"}}, bca.ErrVABillNotFound.Reason},
+		{"partial", "2", "0.01", nil, bca.ReasonMessage{}},
+		{"partial overpaid", "2", "150000.51", nil, bca.ErrVAInvalidAmount.Reason},
+		{"minimum", "3", "50000.00", nil, bca.ReasonMessage{}},
+		{"below minimum", "3", "49999.99", nil, bca.ErrVAInvalidAmount.Reason},
+		{"open", "4", "12345678.90", nil, bca.ReasonMessage{}},
+		{"zero", "4", "0.00", nil, bca.ErrVAInvalidAmount.Reason},
+		{"malformed", "4", "1.234", nil, bca.ErrVAInvalidAmount.Reason},
+		{"unknown bill", "5", "100000.00", nil, bca.ErrVABillNotFound.Reason},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dtoReq := bca.PaymentBillRequest{
+				CompanyCode:     "12345",
+				CustomerNumber:  tt.customerNumber,
+				RequestID:       fmt.Sprintf("2015071315072622214000000019%02d", i),
+				ChannelType:     "6014",
+				CustomerName:    "Customer Name Virtual Account",
+				CurrencyCode:    "IDR",
+				TotalAmount:     bca.MustParseAmount("150000.50"),
+				SubCompany:      "00000",
+				TransactionDate: "15/03/2014 22:07:40",
+				Reference:       "1234567890",
+				DetailBills:     tt.detailBills,
+				FlagAdvice:      "N",
+			}
+			// PaidAmount is sent as is, it may be malformed
+			body, err := json.Marshal(dtoReq)
+			require.NoError(t, err)
+			var fields map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &fields))
+			fields["PaidAmount"] = tt.paidAmount
+			body, err = json.Marshal(fields)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodPost, "/va/payments", bytes.NewReader(body))
+			r.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var dtoResp bca.PaymentBillResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtoResp))
+			require.Equal(t, dtoReq.RequestID, dtoResp.RequestID)
+			if tt.expectedReason == (bca.ReasonMessage{}) {
+				require.Equal(t, bca.VAStatusSuccess, dtoResp.PaymentFlagStatus)
+			} else {
+				require.Equal(t, bca.VAStatusFailed, dtoResp.PaymentFlagStatus)
+				require.Equal(t, tt.expectedReason, dtoResp.PaymentFlagReason)
+			}
+		})
+	}
+
+	require.Equal(t, map[string]int{"1": 2, "2": 1, "3": 1, "4": 1}, processor.credits)
+}
+
+func TestVAPaymentHandler_nilBill(t *testing.T) {
+	processor := &paymentProcessor{credits: map[string]int{}}
+	handler := bca.NewVAPaymentHandlerWithBills(billProviderFunc(func(ctx context.Context, dtoReq bca.InquiryBillRequest) (*bca.Bill, error) {
+		return nil, nil
+	}), processor, nil, vaAuth)
+
+	body := `{"CompanyCode":"12345","CustomerNumber":"123456789","RequestID":"201507131507262221400000001975","ChannelType":"6014",` +
+		`"CustomerName":"Customer Name Virtual Account","CurrencyCode":"IDR","PaidAmount":"150000.00","TotalAmount":"150000.00",` +
+		`"SubCompany":"00000","TransactionDate":"15/03/2014 22:07:40","Reference":"1234567890","DetailBills":[],"FlagAdvice":"N","AdditionalData":""}`
+	r := httptest.NewRequest(http.MethodPost, "/va/payments", strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	var dtoResp bca.PaymentBillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtoResp))
+	require.Equal(t, bca.VAStatusFailed, dtoResp.PaymentFlagStatus)
+	require.Equal(t, bca.ErrVABillNotFound.Reason, dtoResp.PaymentFlagReason)
+	require.Empty(t, processor.credits)
+}
@@ -0,0 +1,141 @@
+package bca_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/purwaren/bca-api"
+	"github.com/stretchr/testify/require"
+)
+
+type billProvider map[string]*bca.Bill
+
+func (p billProvider) InquiryBill(ctx context.Context, dtoReq bca.InquiryBillRequest) (*bca.Bill, error) {
+	bill, ok := p[dtoReq.CustomerNumber]
+	if !ok {
+		return nil, errors.Trace(bca.ErrVABillNotFound)
+	}
+	if bill == nil {
+		return nil, errors.New("database is down")
+	}
+	return bill, nil
+}
+
+// billProviderFunc is an adapter to use ordinary function as bca.BillProvider
+type billProviderFunc func(ctx context.Context, dtoReq bca.InquiryBillRequest) (*bca.Bill, error)
+
+func (f billProviderFunc) InquiryBill(ctx context.Context, dtoReq bca.InquiryBillRequest) (*bca.Bill, error) {
+	return f(ctx, dtoReq)
+}
+
+var vaAuth = bca.VAAuthenticatorFunc(func(r *http.Request) error {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		return errors.Unauthorizedf("invalid access token")
+	}
+	return nil
+})
+
+func TestVAHandler(t *testing.T) {
+	handler := bca.NewVAHandler(billProvider{
+		"123456789": {
+			CustomerName: "Customer Name Virtual Account",
+			CurrencyCode: "IDR",
+			TotalAmount:  bca.MustParseAmount("150000.00"),
+			DetailBills: []bca.DetailBill{
+				{BillDescription: bca.ReasonMessage{Indonesian: "Tagihan", English: "Bill"}, BillAmount: bca.MustParseAmount("150000.00"), BillNumber: "1"},
+			},
+		},
+		"555555555": {
+			CustomerName: "Customer Name Virtual Account",
+			CurrencyCode: "IDR",
+			DetailBills: []bca.DetailBill{
+				{BillNumber: "1", BillAmount: bca.MustParseAmount("100000.00")},
+				{BillNumber: "2", BillAmount: bca.MustParseAmount("50000.5")},
+			},
+		},
+		"987654321": nil,
+	}, vaAuth)
+
+	inquiry := func(authorization, body string) (int, map[string]interface{}) {
+		r := httptest.NewRequest(http.MethodPost, "/va/bills", strings.NewReader(body))
+		r.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var dtoResp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtoResp))
+		return w.Code, dtoResp
+	}
+	body := func(customerNumber, transactionDate string) string {
+		return `{"CompanyCode":"12345","CustomerNumber":"` + customerNumber + `","RequestID":"201507131507262221400000001975",` +
+			`"ChannelType":"6014","TransactionDate":"` + transactionDate + `","AdditionalData":""}`
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		status, dtoResp := inquiry("Bearer other", body("123456789", "15/03/2014 22:07:40"))
+		require.Equal(t, http.StatusUnauthorized, status)
+		require.Equal(t, bca.ErrCodeUnauthorized, dtoResp["ErrorCode"])
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		status, dtoResp := inquiry("Bearer token", "{")
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, bca.ErrCodeInvalidJSON, dtoResp["ErrorCode"])
+	})
+
+	t.Run("multi-bill", func(t *testing.T) {
+		status, dtoResp := inquiry("Bearer token", body("555555555", "15/03/2014 22:07:40"))
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, bca.VAStatusSuccess, dtoResp["InquiryStatus"])
+		require.Equal(t, "150000.50", dtoResp["TotalAmount"])
+		require.Len(t, dtoResp["DetailBills"], 2)
+	})
+
+	t.Run("nil bill is not found", func(t *testing.T) {
+		handler := bca.NewVAHandler(billProviderFunc(func(ctx context.Context, dtoReq bca.InquiryBillRequest) (*bca.Bill, error) {
+			return nil, nil
+		}), vaAuth)
+		r := httptest.NewRequest(http.MethodPost, "/va/bills", strings.NewReader(body("123456789", "15/03/2014 22:07:40")))
+		r.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var dtoResp bca.InquiryBillResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtoResp))
+		require.Equal(t, bca.VAStatusFailed, dtoResp.InquiryStatus)
+		require.Equal(t, bca.ErrVABillNotFound.Reason, dtoResp.InquiryReason)
+	})
+
+	tests := []struct {
+		name            string
+		customerNumber  string
+		transactionDate string
+		expectedStatus  string
+		expectedReason  bca.ReasonMessage
+	}{
+		{"success", "123456789", "15/03/2014 22:07:40", bca.VAStatusSuccess, bca.ReasonMessage{Indonesian: "Sukses", English: "Success"}},
+		{"invalid TransactionDate", "123456789", "2014-03-15", bca.VAStatusFailed, bca.ErrVAInvalidRequest.Reason},
+		{"not found", "111111111", "15/03/2014 22:07:40", bca.VAStatusFailed, bca.ErrVABillNotFound.Reason},
+		{"provider error", "987654321", "15/03/2014 22:07:40", bca.VAStatusFailed, bca.ErrVAGeneral.Reason},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, dtoResp := inquiry("Bearer token", body(tt.customerNumber, tt.transactionDate))
+			require.Equal(t, http.StatusOK, status)
+			require.Equal(t, tt.customerNumber, dtoResp["CustomerNumber"])
+			require.Equal(t, "201507131507262221400000001975", dtoResp["RequestID"])
+			require.Equal(t, tt.expectedStatus, dtoResp["InquiryStatus"])
+			require.Equal(t, map[string]interface{}{"Indonesian": tt.expectedReason.Indonesian, "English": tt.expectedReason.English}, dtoResp["InquiryReason"])
+			require.NotNil(t, dtoResp["DetailBills"])
+			if tt.expectedStatus == bca.VAStatusSuccess {
+				require.Equal(t, "150000.00", dtoResp["TotalAmount"])
+				require.Len(t, dtoResp["DetailBills"], 1)
+			}
+		})
+	}
+}